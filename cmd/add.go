@@ -6,9 +6,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"mcpm/internal/builder"
 )
 
 var (
@@ -17,10 +19,11 @@ var (
 	addClaudeCode bool
 	addGeminiCLI  bool
 	addGlobal     bool
+	addImage      string
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add <name> <command-or-url> [args...]",
+	Use:   "add <name> [<command-or-url>] [args...]",
 	Short: "Add an MCP server directly without cloning",
 	Long: `Add an existing MCP server to Claude Code and/or Gemini CLI.
 
@@ -28,6 +31,7 @@ This is useful for:
   - HTTP/SSE MCP servers (remote endpoints)
   - Already installed local servers
   - Pre-built binaries
+  - Container images
 
 Examples:
   # Add HTTP server
@@ -49,12 +53,24 @@ Examples:
   mcpm add myserver /path/to/server --gemini
 
   # Add globally (available in all projects)
-  mcpm add myserver /path/to/server --global`,
-	Args: cobra.MinimumNArgs(2),
+  mcpm add myserver /path/to/server --global
+
+  # Add a container image, run with docker/podman (env vars are forwarded with -e)
+  mcpm add github --image ghcr.io/github/github-mcp-server -e GITHUB_PERSONAL_ACCESS_TOKEN=xxx`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if addImage != "" {
+			return cobra.MinimumNArgs(1)(cmd, args)
+		}
+		return cobra.MinimumNArgs(2)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		name := args[0]
-		commandOrURL := args[1]
-		serverArgs := args[2:]
+		var commandOrURL string
+		var serverArgs []string
+		if addImage == "" {
+			commandOrURL = args[1]
+			serverArgs = args[2:]
+		}
 
 		// Default to both if neither specified
 		if !addClaudeCode && !addGeminiCLI {
@@ -71,6 +87,23 @@ Examples:
 			}
 		}
 
+		// Run the image with docker/podman, forwarding env vars into the container
+		if addImage != "" {
+			rt, err := builder.ContainerRuntime()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			var envNames []string
+			for key := range env {
+				envNames = append(envNames, key)
+			}
+			sort.Strings(envNames)
+			commandOrURL = rt
+			serverArgs = append(builder.ContainerRunArgs(addImage, envNames), args[1:]...)
+			addTransport = "stdio"
+		}
+
 		// Detect transport type if not specified
 		if addTransport == "" {
 			if strings.HasPrefix(commandOrURL, "http://") || strings.HasPrefix(commandOrURL, "https://") {
@@ -122,8 +155,12 @@ func addToClaudeCode(cwd, name, commandOrURL string, args []string, env map[stri
 		cmdArgs = append(cmdArgs, "--env", fmt.Sprintf("%s=%s", key, value))
 	}
 
-	// Add server name and command/URL
-	cmdArgs = append(cmdArgs, name, commandOrURL)
+	// Add server name and command/URL, after "--" so server flags aren't parsed by claude
+	if transport == "stdio" {
+		cmdArgs = append(cmdArgs, name, "--", commandOrURL)
+	} else {
+		cmdArgs = append(cmdArgs, name, commandOrURL)
+	}
 
 	// Add server args
 	cmdArgs = append(cmdArgs, args...)
@@ -212,5 +249,6 @@ func init() {
 	addCmd.Flags().BoolVar(&addClaudeCode, "claude", false, "Add only to Claude Code")
 	addCmd.Flags().BoolVar(&addGeminiCLI, "gemini", false, "Add only to Gemini CLI")
 	addCmd.Flags().BoolVarP(&addGlobal, "global", "g", false, "Add globally (available in all projects)")
+	addCmd.Flags().StringVar(&addImage, "image", "", "Container image to run with docker/podman instead of a command")
 	rootCmd.AddCommand(addCmd)
 }
@@ -1,16 +1,26 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
+	"mcpm/internal/builder"
+	"mcpm/internal/fetcher"
 	"mcpm/internal/tui"
 )
 
-var installGlobal bool
+var (
+	installGlobal  bool
+	installBuilder string
+	installLink    bool
+	installSHA256  string
+	installRelease string
+)
 
 var installCmd = &cobra.Command{
 	Use:   "install [scheme]",
@@ -21,54 +31,119 @@ Examples:
   mcpm install @modelcontextprotocol/server-filesystem
   mcpm install gl:@gitlab-org/my-server
   mcpm install gl:rh:@sp-ai/lumino/lumino-mcp-server
+  mcpm install srht:@~user/weather-mcp
   mcpm install https://github.com/user/repo.git
+  mcpm install npm:@modelcontextprotocol/server-filesystem@^2025.1
+  mcpm install pypi:mcp-server-fetch==2025.1.17
+  mcpm install ./my-server
+
+  # Build a local server in place, so edits are picked up by update
+  mcpm install ./my-server --link
+
+  # Extract a release archive and build it, or run the binary it ships
+  mcpm install https://example.com/weather-mcp-1.2.0.tar.gz --sha256 <hex>
+
+  # Download the release binary for this platform instead of building
+  mcpm install @org/weather-mcp --release
+  mcpm install @org/weather-mcp --release=v1.2.0
+
+  # Unpack an MCP Bundle and fill in its user_config
+  mcpm install ./weather-1.0.0.mcpb
 
   # Install globally (available in all projects)
   mcpm install @modelcontextprotocol/server-filesystem --global
 
+  # Build and run the repo's Dockerfile instead of building natively
+  mcpm install @org/repo --builder container
+
 Schemes:
-  @org/repo           GitHub (default)
+  @org/repo           GitHub (default, same as gh:@org/repo)
   gl:@org/repo        GitLab.com
   gl:rh:@org/repo     GitLab Red Hat (gitlab.cee.redhat.com)
-  https://...         Direct URL`,
+  bb:@workspace/repo  Bitbucket
+  cb:@owner/repo      Codeberg
+  srht:@~user/repo    sourcehut
+  gitea:<host>:@o/r   Gitea or Forgejo on <host> (also forgejo:<host>:@o/r)
+  <prefix>:@org/repo  Scheme from ~/.mcpm.yaml (see mcpm scheme list)
+  https://...         Direct URL
+  npm:pkg[@version]   npm package, installed into .mcp/servers/<name>
+  pypi:pkg[==version] PyPI package, installed into a venv in .mcp/servers/<name>
+  go:pkg[@version]    Go main package, go installed into .mcp/servers/<name>
+  ./path, file://...  Local directory, copied into .mcp/servers/<name> (or --link)
+  *.tar.gz, *.zip     Release archive (URL or path), extracted into .mcp/servers/<name>
+  *.mcpb, *.dxt       MCP Bundle (URL or path), unpacked and configured from its manifest.json`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		repoRef := args[0]
-		url, _ := parseScheme(repoRef)
+		src, err := parseSource(repoRef)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if installLink {
+			if src.Kind != "local" {
+				fmt.Println("Error: --link only applies to local directories")
+				os.Exit(1)
+			}
+			src.Link = true
+		}
+		if installSHA256 != "" {
+			if src.Kind != "archive" {
+				fmt.Println("Error: --sha256 only applies to archives")
+				os.Exit(1)
+			}
+			src.SHA256 = installSHA256
+		}
+		if installRelease != "" {
+			if src.Kind != "git" {
+				fmt.Println("Error: --release only applies to GitHub and GitLab repos")
+				os.Exit(1)
+			}
+			src.Kind = "release"
+			src.Version = installRelease
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
 
 		// Initialize and run the TUI with alt screen to avoid TTY issues
 		p := tea.NewProgram(
-			tui.NewInstallModel(url, repoRef, installGlobal),
+			tui.NewInstallModel(ctx, src, repoRef, installGlobal, builder.Options{Builder: installBuilder}),
 			tea.WithAltScreen(),
 		)
-		if _, err := p.Run(); err != nil {
+		final, err := p.Run()
+		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
+		// The alt screen is gone, keep the outcome visible
+		fmt.Println(final.View())
 	},
 }
 
-func parseScheme(input string) (string, string) {
-	// GitLab Red Hat (gitlab.cee.redhat.com)
-	if strings.HasPrefix(input, "gl:rh:@") {
-		return "https://gitlab.cee.redhat.com/" + input[7:] + ".git", "GitLab Red Hat"
-	}
-	// GitLab.com
-	if strings.HasPrefix(input, "gl:@") {
-		return "https://gitlab.com/" + input[4:] + ".git", "GitLab"
-	}
-	// GitHub shorthand
-	if strings.HasPrefix(input, "@") {
-		return "https://github.com/" + input[1:] + ".git", "GitHub"
+// parseSource works out where to install from: an archive, a package, a
+// local directory, or else a git repo
+func parseSource(input string) (fetcher.Source, error) {
+	for _, parse := range []func(string) (fetcher.Source, bool, error){
+		fetcher.ParseArchive, fetcher.ParsePackage, fetcher.ParseLocal,
+	} {
+		if src, ok, err := parse(input); ok || err != nil {
+			return src, err
+		}
 	}
-	// Direct URL
-	if strings.HasPrefix(input, "http") {
-		return input, "Custom URL"
+	url, err := fetcher.ExpandScheme(input)
+	if err != nil {
+		return fetcher.Source{}, err
 	}
-	return input, "Unknown"
+	return fetcher.Source{Kind: "git", URL: url}, nil
 }
 
 func init() {
 	installCmd.Flags().BoolVarP(&installGlobal, "global", "g", false, "Install globally (available in all projects)")
+	installCmd.Flags().BoolVar(&installLink, "link", false, "Build a local directory in place and register it there instead of copying it")
+	installCmd.Flags().StringVar(&installSHA256, "sha256", "", "Expected SHA-256 checksum of an archive")
+	installCmd.Flags().StringVar(&installRelease, "release", "", "Download the release binary for this platform, from the latest release or the given tag")
+	installCmd.Flags().Lookup("release").NoOptDefVal = "latest"
+	installCmd.Flags().StringVar(&installBuilder, "builder", "", "Force a builder instead of auto-detecting: "+strings.Join(builder.Builders, ", "))
 	rootCmd.AddCommand(installCmd)
 }
@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"mcpm/internal/fetcher"
@@ -28,10 +27,16 @@ Examples:
 			return
 		}
 
-		cwd, _ := os.Getwd()
 		fmt.Println("Installed MCP servers:")
 		for _, name := range servers {
-			serverPath := filepath.Join(cwd, ".mcp", "servers", name)
+			serverPath, err := fetcher.GetServerPath(name)
+			if err != nil {
+				fmt.Printf("  • %s (%v)\n", name, err)
+				continue
+			}
+			if src, _ := fetcher.LoadSource(name); src.Link {
+				serverPath += ", linked"
+			}
 			fmt.Printf("  • %s (%s)\n", name, serverPath)
 		}
 	},
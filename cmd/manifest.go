@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"mcpm/internal/builder"
+)
+
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Work with mcp.json manifests",
+	Long: `Tools for MCP server authors writing an mcp.json manifest.
+
+Examples:
+  # Check ./mcp.json
+  mcpm manifest validate
+
+  # Check a specific file
+  mcpm manifest validate path/to/mcp.json
+
+  # Print the JSON Schema (for editor completion)
+  mcpm manifest schema > mcp.schema.json`,
+}
+
+var manifestValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Validate an mcp.json against the manifest schema",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := "mcp.json"
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		errs := builder.ValidateManifest(data)
+		if len(errs) > 0 {
+			fmt.Printf("%s is invalid:\n", path)
+			for _, err := range errs {
+				fmt.Printf("  • %v\n", err)
+			}
+			os.Exit(1)
+		}
+		fmt.Printf("%s is valid\n", path)
+	},
+}
+
+var manifestSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for mcp.json",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Stdout.Write(builder.ManifestSchema)
+	},
+}
+
+func init() {
+	manifestCmd.AddCommand(manifestValidateCmd)
+	manifestCmd.AddCommand(manifestSchemaCmd)
+	rootCmd.AddCommand(manifestCmd)
+}
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"mcpm/internal/builder"
+	"mcpm/internal/injector"
 )
 
 var (
@@ -93,6 +95,13 @@ func removeFromClaudeCode(cwd, name, scope string) error {
 }
 
 func removeFromGeminiCLI(cwd, name string, global bool) error {
+	// An installed extension is removed with its directory
+	if dir, err := injector.GeminiExtensionsDir(cwd, global); err == nil && builder.ValidExtensionName(name) {
+		if _, err := os.Stat(filepath.Join(dir, name, "gemini-extension.json")); err == nil {
+			return os.RemoveAll(filepath.Join(dir, name))
+		}
+	}
+
 	var configPath string
 
 	if global {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"mcpm/internal/config"
 )
 
 var cfgFile string
@@ -42,6 +43,7 @@ func initConfig() {
 		viper.SetConfigName(".mcpm")
 	}
 
+	config.SetDefaults()
 	viper.AutomaticEnv()
 	if err := viper.ReadInConfig(); err == nil {
 		// Config loaded
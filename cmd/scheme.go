@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"mcpm/internal/config"
+	"mcpm/internal/fetcher"
+)
+
+var schemeCmd = &cobra.Command{
+	Use:   "scheme",
+	Short: "Work with source shorthands like gl:@org/repo",
+	Long: `Source schemes expand <prefix>:@org/repo into a git URL. GitHub (also
+plain @org/repo), GitLab, Bitbucket, Codeberg, sourcehut and any
+Gitea/Forgejo host (gitea:<host>:@owner/repo) are built in; more are added under "schemes"
+in ~/.mcpm.yaml:
+
+  schemes:
+    acme:
+      name: ACME GitLab
+      url: https://gitlab.acme.dev/{path}.git
+      layout: groups                 # groups (nested), owner/repo or sourcehut
+      protocol: ssh                  # https (default) or ssh
+      credentials: env:ACME_TOKEN    # or file:~/.config/acme-token
+      forge: gitlab                  # github or gitlab, for install --release
+
+Examples:
+  mcpm scheme list
+  mcpm install acme:@platform/weather-mcp
+  mcpm install gitea:git.example.com:@owner/weather-mcp`,
+}
+
+var schemeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the available source schemes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		schemes, err := config.Schemes()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SCHEME\tNAME\tURL\tCREDENTIALS\tFROM")
+		for _, s := range schemes {
+			from := "config"
+			if s.Builtin {
+				from = "built-in"
+			}
+			credentials := s.Credentials
+			if credentials == "" {
+				credentials = "-"
+			}
+			fmt.Fprintf(w, "%s:@%s\t%s\t%s\t%s\t%s\n", s.Prefix, layoutExample(s.Layout), s.Name, fetcher.SchemeURL(s, "{path}"), credentials, from)
+		}
+		w.Flush()
+	},
+}
+
+// layoutExample shows what repo paths look like under a scheme
+func layoutExample(layout string) string {
+	switch layout {
+	case "owner/repo":
+		return "owner/repo"
+	case "sourcehut":
+		return "~user/repo"
+	}
+	return "group/repo"
+}
+
+func init() {
+	schemeCmd.AddCommand(schemeListCmd)
+	rootCmd.AddCommand(schemeCmd)
+}
@@ -1,24 +1,31 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
+	"mcpm/internal/builder"
 	"mcpm/internal/fetcher"
 	"mcpm/internal/tui"
 )
 
 var (
-	updateAll    bool
-	updateGlobal bool
+	updateAll     bool
+	updateGlobal  bool
+	updateBuilder string
 )
 
 var updateCmd = &cobra.Command{
 	Use:   "update [name]",
 	Short: "Update an installed MCP server from its remote repository",
 	Long: `Pull the latest changes from the remote repository and rebuild the MCP server.
+Servers installed from a package registry move to the newest version
+matching the one requested at install.
 
 Examples:
   # Update a specific server
@@ -31,6 +38,9 @@ Examples:
   mcpm update server-filesystem --global`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
 		if updateAll {
 			servers, err := fetcher.ListServers()
 			if err != nil {
@@ -44,8 +54,11 @@ Examples:
 			}
 
 			for _, name := range servers {
+				if ctx.Err() != nil {
+					break
+				}
 				fmt.Printf("Updating %s...\n", name)
-				if err := updateServer(name, updateGlobal); err != nil {
+				if err := updateServer(ctx, name, updateGlobal); err != nil {
 					fmt.Printf("  Error: %v\n", err)
 				} else {
 					fmt.Printf("  Updated successfully\n")
@@ -60,35 +73,69 @@ Examples:
 		}
 
 		name := args[0]
-		if err := updateServer(name, updateGlobal); err != nil {
+		if err := updateServer(ctx, name, updateGlobal); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
 	},
 }
 
-func updateServer(name string, global bool) error {
+func updateServer(ctx context.Context, name string, global bool) error {
 	// Get server path
 	serverPath, err := fetcher.GetServerPath(name)
 	if err != nil {
 		return err
 	}
 
-	// Pull latest changes
-	fmt.Printf("  Pulling latest changes...\n")
-	if err := fetcher.Pull(serverPath); err != nil {
-		return fmt.Errorf("failed to pull: %w", err)
+	src, err := fetcher.LoadSource(name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case src.IsPackage():
+		// Reinstalling resolves the newest matching version
+		fmt.Printf("  Updating %s package %s...\n", src.Kind, src.Package)
+	case src.Kind == "local" && src.Link:
+		fmt.Printf("  Rebuilding %s in place...\n", src.Path)
+	case src.Kind == "local":
+		fmt.Printf("  Copying %s...\n", src.Path)
+		if _, err := fetcher.CopyLocal(src); err != nil {
+			return err
+		}
+		fmt.Printf("  Rebuilding...\n")
+	case src.Kind == "archive":
+		fmt.Printf("  Fetching archive...\n")
+		if _, err := fetcher.FetchArchive(ctx, src); err != nil {
+			return err
+		}
+		fmt.Printf("  Rebuilding...\n")
+	case src.Kind == "release":
+		fmt.Printf("  Downloading release...\n")
+		if _, src.Resolved, err = fetcher.FetchRelease(ctx, src); err != nil {
+			return err
+		}
+		fmt.Printf("  Installed %s\n", src.Resolved)
+	default:
+		// Pull latest changes
+		fmt.Printf("  Pulling latest changes...\n")
+		if err := fetcher.Pull(ctx, serverPath); err != nil {
+			return fmt.Errorf("failed to pull: %w", err)
+		}
+		fmt.Printf("  Rebuilding...\n")
 	}
 
 	// Rebuild using TUI
-	fmt.Printf("  Rebuilding...\n")
 	p := tea.NewProgram(
-		tui.NewUpdateModel(serverPath, name, global),
+		tui.NewUpdateModel(ctx, serverPath, name, src, global, builder.Options{Builder: updateBuilder}),
 		tea.WithAltScreen(),
 	)
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		return fmt.Errorf("rebuild failed: %w", err)
 	}
+	// The alt screen is gone, keep the outcome visible
+	fmt.Println(final.View())
 
 	return nil
 }
@@ -96,5 +143,6 @@ func updateServer(name string, global bool) error {
 func init() {
 	updateCmd.Flags().BoolVarP(&updateAll, "all", "a", false, "Update all installed servers")
 	updateCmd.Flags().BoolVarP(&updateGlobal, "global", "g", false, "Re-register globally after update")
+	updateCmd.Flags().StringVar(&updateBuilder, "builder", "", "Force a builder instead of auto-detecting: "+strings.Join(builder.Builders, ", "))
 	rootCmd.AddCommand(updateCmd)
 }
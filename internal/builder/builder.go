@@ -1,57 +1,232 @@
 package builder
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"mcpm/internal/config"
 )
 
-func DetectAndBuild(repoPath string) (*BuildResult, error) {
+// DetectAndBuild detects the project type and builds it. Cancelling ctx
+// kills any running build step.
+func DetectAndBuild(ctx context.Context, repoPath string, opts Options) (*BuildResult, error) {
 	absPath, _ := filepath.Abs(repoPath)
 
+	ctx, cancel := config.WithBuildTimeout(ctx)
+	defer cancel()
+
 	// 1. Check for explicit mcp.json
+	var m *Manifest
 	manifestPath := filepath.Join(absPath, "mcp.json")
 	if _, err := os.Stat(manifestPath); err == nil {
-		return buildFromManifest(absPath, manifestPath)
+		if m, err = loadManifest(manifestPath); err != nil {
+			return nil, err
+		}
+		if len(m.Servers) > 0 {
+			if opts.Builder != "" {
+				return nil, fmt.Errorf("--builder doesn't apply to the servers listed in mcp.json")
+			}
+			return buildManifestServers(ctx, absPath, m)
+		}
+		// A builder the user names replaces the manifest's runCmd
+		if m.RunCmd != "" && opts.Builder == "" {
+			return buildFromManifest(ctx, absPath, m)
+		}
 	}
 
-	// 2. Heuristics
-	if exists(filepath.Join(absPath, "package.json")) {
-		return buildNode(absPath)
+	// 2. MCP Bundle (.mcpb, .dxt): ships ready to run and declares its config
+	bundlePath := filepath.Join(absPath, "manifest.json")
+	if m == nil && opts.Builder == "" && exists(bundlePath) {
+		b, err := readBundle(bundlePath)
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			return buildFromBundle(absPath, b)
+		}
 	}
-	if exists(filepath.Join(absPath, "pyproject.toml")) || exists(filepath.Join(absPath, "requirements.txt")) {
-		return buildPython(absPath)
+
+	// 3. Gemini CLI extension, whose servers are registered with the other clients
+	if m == nil && opts.Builder == "" && exists(filepath.Join(absPath, "gemini-extension.json")) {
+		ext, err := ReadGeminiExtension(absPath)
+		if err != nil {
+			return nil, err
+		}
+		return buildGeminiExtension(ctx, absPath, ext)
 	}
-	if exists(filepath.Join(absPath, "go.mod")) {
-		return buildGo(absPath)
+
+	// 4. Claude Code plugin, whose bundled servers are registered
+	if m == nil && opts.Builder == "" && exists(filepath.Join(absPath, ".claude-plugin", "plugin.json")) {
+		plugin, servers, err := readClaudePlugin(absPath)
+		if err != nil {
+			return nil, err
+		}
+		return buildClaudePlugin(absPath, plugin, servers)
 	}
 
-	return nil, fmt.Errorf("could not detect project type (no mcp.json, package.json, requirements.txt, or go.mod)")
-}
+	// 5. Published package described by the MCP registry's server.json,
+	// unless the user asked for a specific builder
+	serverJSONPath := filepath.Join(absPath, "server.json")
+	if m == nil && opts.Builder == "" && exists(serverJSONPath) {
+		s, err := readServerJSON(serverJSONPath)
+		if err != nil {
+			return nil, err
+		}
+		if len(s.Packages) > 0 {
+			return buildFromServerJSON(absPath, s)
+		}
+	}
+
+	// 6. Monorepo with several servers under packages/
+	if m == nil && opts.Builder == "" {
+		if dirs := serverPackages(absPath); len(dirs) > 1 {
+			return buildPackages(ctx, absPath, dirs)
+		}
+	}
+
+	// 7. Heuristics, unless the manifest or the user names the type
+	projectType := detectProjectType(absPath)
+	if m != nil && m.Type != "" {
+		projectType = m.Type
+	}
+	if opts.Builder != "" {
+		projectType = opts.Builder
+	}
 
-func buildFromManifest(repoPath, manifestPath string) (*BuildResult, error) {
-	data, err := os.ReadFile(manifestPath)
+	if m != nil {
+		// Install steps first, so the builder sees what they fetch
+		for _, step := range m.Install {
+			if err := runShellCmd(ctx, absPath, step); err != nil {
+				return nil, err
+			}
+		}
+	}
+	result, err := buildType(ctx, absPath, projectType, m)
 	if err != nil {
 		return nil, err
 	}
-	var m Manifest
-	if err := json.Unmarshal(data, &m); err != nil {
-		return nil, fmt.Errorf("invalid mcp.json: %w", err)
+
+	// 8. Smithery's start command and config form, on top of the build
+	smitheryPath := filepath.Join(absPath, "smithery.yaml")
+	if !exists(smitheryPath) {
+		smitheryPath = filepath.Join(absPath, "smithery.yml")
+	}
+	if exists(smitheryPath) {
+		s, err := readSmithery(smitheryPath)
+		if err != nil {
+			return nil, err
+		}
+		if start := s.stdio(); start != nil {
+			if err := applySmithery(ctx, absPath, result, start); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	if m.BuildCmd != "" {
-		if err := runShellCmd(repoPath, m.BuildCmd); err != nil {
+	if m != nil {
+		// Custom build steps on top of the builder's own
+		for _, step := range m.buildSteps() {
+			if err := runShellCmd(ctx, absPath, step); err != nil {
+				return nil, err
+			}
+		}
+		result.EnvNeeds = append(result.EnvNeeds, m.envNeeds()...)
+		m.applyTransport(result)
+	}
+	// 9. Suggest env vars the repo reads, unless the manifest declares them
+	if m == nil || len(m.envNeeds()) == 0 {
+		known := make(map[string]bool)
+		for _, e := range result.EnvNeeds {
+			known[e.Name] = true
+		}
+		for name := range result.Env {
+			known[name] = true
+		}
+		result.EnvNeeds = append(result.EnvNeeds, discoverEnv(absPath, known)...)
+	}
+
+	if result.Name == "" {
+		result.Name = filepath.Base(absPath)
+	}
+	return result, nil
+}
+
+// buildType builds the project in absPath with the builder for projectType
+func buildType(ctx context.Context, absPath, projectType string, m *Manifest) (*BuildResult, error) {
+	switch projectType {
+	case "deno":
+		return buildDeno(ctx, absPath, m)
+	case "bun":
+		return buildBun(ctx, absPath)
+	case "node":
+		return buildNode(ctx, absPath)
+	case "python":
+		return buildPython(ctx, absPath)
+	case "go":
+		return buildGo(ctx, absPath, m)
+	case "rust":
+		return buildRust(ctx, absPath)
+	case "jvm":
+		return buildJVM(ctx, absPath)
+	case "container":
+		return buildContainer(ctx, absPath)
+	case "prebuilt":
+		return buildPrebuilt(absPath)
+	case "":
+		return nil, fmt.Errorf("could not detect project type (no mcp.json, deno.json, package.json, requirements.txt, go.mod, Cargo.toml, pom.xml, build.gradle, Dockerfile, or prebuilt executable)")
+	default:
+		return nil, fmt.Errorf("unknown project type %q (expected one of %s)", projectType, strings.Join(Builders, ", "))
+	}
+}
+
+// detectProjectType guesses the project type from the files in the repo root
+func detectProjectType(path string) string {
+	switch {
+	case findDenoConfig(path) != "":
+		return "deno"
+	case exists(filepath.Join(path, "package.json")) && isBunProject(path):
+		return "bun"
+	case exists(filepath.Join(path, "package.json")):
+		return "node"
+	case exists(filepath.Join(path, "pyproject.toml")) || exists(filepath.Join(path, "requirements.txt")):
+		return "python"
+	case exists(filepath.Join(path, "go.mod")):
+		return "go"
+	case exists(filepath.Join(path, "Cargo.toml")):
+		return "rust"
+	case isMavenProject(path) || gradleBuildFile(path) != "":
+		return "jvm"
+	case findDockerfile(path) != "":
+		// Only when nothing else could build it natively
+		return "container"
+	case len(findPrebuiltBinaries(path)) > 0:
+		// Nothing to build, e.g. an extracted release archive
+		return "prebuilt"
+	}
+	return ""
+}
+
+func buildFromManifest(ctx context.Context, repoPath string, m *Manifest) (*BuildResult, error) {
+	for _, step := range m.steps() {
+		if err := runShellCmd(ctx, repoPath, step); err != nil {
 			return nil, err
 		}
 	}
 
-	// Ensure RunCmd is absolute or resolved?
-	// For manifest, we assume the user knows what they are doing, but if it is "python", we might want the venv python.
-	// For MVP, take literally.
-	return &BuildResult{
-		Command:  m.RunCmd,
-		Args:     m.Args,
-		EnvNeeds: m.RequiredEnv,
-	}, nil
+	// Paths are relative to the repo, e.g. "runCmd": ".venv/bin/python"
+	args := make([]string, len(m.Args))
+	for i, arg := range m.Args {
+		args[i] = resolveRepoPath(repoPath, arg)
+	}
+	result := &BuildResult{
+		Name:     filepath.Base(repoPath),
+		Command:  resolveRepoPath(repoPath, m.RunCmd),
+		Args:     args,
+		EnvNeeds: m.envNeeds(),
+	}
+	m.applyTransport(result)
+	return result, nil
 }
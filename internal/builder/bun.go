@@ -0,0 +1,60 @@
+package builder
+
+import (
+	"context"
+	"path/filepath"
+)
+
+var bunRuntime = jsRuntime{
+	command:    "bun",
+	runArgs:    []string{"run"},
+	extensions: []string{".ts", ".tsx", ".mts", ".js", ".mjs", ".cjs"},
+	fallbacks: []string{
+		"dist/index.js", "build/index.js",
+		"src/index.ts", "index.ts", "index.js",
+	},
+}
+
+func isBunProject(path string) bool {
+	return exists(filepath.Join(path, "bun.lockb")) || exists(filepath.Join(path, "bun.lock"))
+}
+
+func buildBun(ctx context.Context, path string) (*BuildResult, error) {
+	pkg, err := installBunDeps(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bun runs TypeScript directly, so a declared src/*.ts entry is fine
+	candidates, err := bunRuntime.resolveEntry(path, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BuildResult{EnvNeeds: []EnvVar{}}
+	result.Use(candidates[0])
+	if len(candidates) > 1 {
+		result.Candidates = candidates
+	}
+	return result, nil
+}
+
+// installBunDeps installs dependencies with bun and runs the build script,
+// if any
+func installBunDeps(ctx context.Context, path string) (PackageJSON, error) {
+	if err := runShellCmd(ctx, path, "bun install"); err != nil {
+		return PackageJSON{}, err
+	}
+
+	pkg, err := readPackageJSON(path)
+	if err != nil {
+		return PackageJSON{}, err
+	}
+
+	if _, hasBuild := pkg.Scripts["build"]; hasBuild {
+		if err := runShellCmd(ctx, path, "bun run build"); err != nil {
+			return PackageJSON{}, err
+		}
+	}
+	return pkg, nil
+}
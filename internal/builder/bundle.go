@@ -0,0 +1,226 @@
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// BundleManifest is the manifest.json of an MCP Bundle (.mcpb), formerly
+// a desktop extension (.dxt). Bundles ship ready to run.
+type BundleManifest struct {
+	ManifestVersion string                      `json:"manifest_version"`
+	DXTVersion      string                      `json:"dxt_version"` // Older name of manifest_version
+	Name            string                      `json:"name"`
+	Version         string                      `json:"version"`
+	Server          *BundleServer               `json:"server"`
+	UserConfig      map[string]BundleUserConfig `json:"user_config"`
+	Compatibility   struct {
+		Platforms []string `json:"platforms"` // "darwin", "win32", "linux"
+	} `json:"compatibility"`
+}
+
+type BundleServer struct {
+	Type       string           `json:"type"`        // "node", "python", "binary" or "uv"
+	EntryPoint string           `json:"entry_point"` // Relative to the bundle
+	MCPConfig  *BundleMCPConfig `json:"mcp_config"`
+}
+
+// BundleMCPConfig is how to start the server. Values may use ${__dirname},
+// ${user_config.NAME}, ${HOME} and the other bundle variables.
+type BundleMCPConfig struct {
+	Command           string                     `json:"command"`
+	Args              []string                   `json:"args"`
+	Env               map[string]string          `json:"env"`
+	PlatformOverrides map[string]BundleMCPConfig `json:"platform_overrides"`
+}
+
+// BundleUserConfig is one setting the bundle asks the user for
+type BundleUserConfig struct {
+	Type        string      `json:"type"` // "string", "number", "boolean", "directory" or "file"
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Required    bool        `json:"required"`
+	Default     interface{} `json:"default"`
+	Multiple    bool        `json:"multiple"` // A list, expanded into one arg per value
+	Sensitive   bool        `json:"sensitive"`
+	Min         *float64    `json:"min"`
+	Max         *float64    `json:"max"`
+}
+
+// readBundle reads a bundle manifest.json. It returns nil for a
+// manifest.json that isn't a bundle's.
+func readBundle(path string) (*BundleManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b BundleManifest
+	if err := json.Unmarshal(data, &b); err != nil || (b.ManifestVersion == "" && b.DXTVersion == "") {
+		return nil, nil
+	}
+	if b.Server == nil {
+		return nil, fmt.Errorf("bundle manifest.json declares no server")
+	}
+	return &b, nil
+}
+
+// bundlePlatform is the running OS under the name bundles use
+func bundlePlatform() string {
+	if runtime.GOOS == "windows" {
+		return "win32"
+	}
+	return runtime.GOOS
+}
+
+// bundleReplacer expands the variables bundles may use, other than
+// ${user_config.NAME}
+func bundleReplacer(bundlePath string) *strings.Replacer {
+	home, _ := os.UserHomeDir()
+	sep := string(os.PathSeparator)
+	return strings.NewReplacer(
+		"${__dirname}", bundlePath,
+		"${HOME}", home,
+		"${DESKTOP}", filepath.Join(home, "Desktop"),
+		"${DOCUMENTS}", filepath.Join(home, "Documents"),
+		"${DOWNLOADS}", filepath.Join(home, "Downloads"),
+		"${pathSeparator}", sep,
+		"${/}", sep,
+	)
+}
+
+// mcpConfig returns the server's start command for this platform, derived
+// from the server type and entry point when mcp_config is missing
+func (b *BundleManifest) mcpConfig() (BundleMCPConfig, error) {
+	if b.Server.MCPConfig == nil {
+		entry := "${__dirname}/" + b.Server.EntryPoint
+		switch {
+		case b.Server.EntryPoint == "":
+			return BundleMCPConfig{}, fmt.Errorf("bundle manifest.json has neither mcp_config nor entry_point")
+		case b.Server.Type == "node":
+			return BundleMCPConfig{Command: "node", Args: []string{entry}}, nil
+		case b.Server.Type == "python":
+			return BundleMCPConfig{Command: "python3", Args: []string{entry}}, nil
+		case b.Server.Type == "binary":
+			return BundleMCPConfig{Command: entry}, nil
+		default:
+			return BundleMCPConfig{}, fmt.Errorf("bundle server type %q needs an mcp_config", b.Server.Type)
+		}
+	}
+
+	cfg := *b.Server.MCPConfig
+	if o, ok := cfg.PlatformOverrides[bundlePlatform()]; ok {
+		if o.Command != "" {
+			cfg.Command = o.Command
+		}
+		if o.Args != nil {
+			cfg.Args = o.Args
+		}
+		env := make(map[string]string)
+		for name, value := range cfg.Env {
+			env[name] = value
+		}
+		for name, value := range o.Env {
+			env[name] = value
+		}
+		cfg.Env = env
+	}
+	if cfg.Command == "" {
+		return BundleMCPConfig{}, fmt.Errorf("bundle mcp_config has no command")
+	}
+	return cfg, nil
+}
+
+// configFields turns user_config into form fields, required ones first
+func (b *BundleManifest) configFields(expand *strings.Replacer) []EnvVar {
+	names := make([]string, 0, len(b.UserConfig))
+	for name := range b.UserConfig {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := b.UserConfig[names[i]].Required, b.UserConfig[names[j]].Required
+		if ri != rj {
+			return ri
+		}
+		return names[i] < names[j]
+	})
+
+	fields := make([]EnvVar, 0, len(names))
+	for _, name := range names {
+		setting := b.UserConfig[name]
+		field := EnvVar{
+			Name:        name,
+			Description: setting.Description,
+			Optional:    !setting.Required,
+			Secret:      setting.Sensitive,
+			Type:        setting.Type,
+		}
+		if field.Description == "" {
+			field.Description = setting.Title
+		}
+		var hints []string
+		switch setting.Type {
+		case "directory", "file":
+			hints = append(hints, setting.Type)
+		case "number":
+			if setting.Min != nil && setting.Max != nil {
+				hints = append(hints, fmt.Sprintf("%g to %g", *setting.Min, *setting.Max))
+			}
+		}
+		if setting.Multiple {
+			field.Type = "array"
+			hints = append(hints, "comma-separated")
+		}
+		if len(hints) > 0 {
+			field.Description = strings.TrimSpace(field.Description + " (" + strings.Join(hints, ", ") + ")")
+		}
+		if setting.Default != nil {
+			field.Default = expand.Replace(configString(setting.Default))
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// buildFromBundle registers an unpacked MCP Bundle: its mcp_config with the
+// bundle variables expanded, and its user_config as the config form
+func buildFromBundle(bundlePath string, b *BundleManifest) (*BuildResult, error) {
+	if platforms := b.Compatibility.Platforms; len(platforms) > 0 && !contains(platforms, bundlePlatform()) {
+		return nil, fmt.Errorf("bundle supports %s, not %s", strings.Join(platforms, ", "), bundlePlatform())
+	}
+	cfg, err := b.mcpConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	expand := bundleReplacer(bundlePath)
+	result := &BuildResult{
+		Name:     filepath.Base(bundlePath),
+		Command:  resolveRepoPath(bundlePath, expand.Replace(cfg.Command)),
+		EnvNeeds: []EnvVar{},
+		Config:   b.configFields(expand),
+	}
+	for _, arg := range cfg.Args {
+		result.Args = append(result.Args, expand.Replace(arg))
+	}
+	if len(cfg.Env) > 0 {
+		result.Env = make(map[string]string)
+		for name, value := range cfg.Env {
+			result.Env[name] = expand.Replace(value)
+		}
+	}
+
+	// Zip archives don't always keep the executable bit
+	if runtime.GOOS != "windows" && strings.HasPrefix(result.Command, bundlePath+string(os.PathSeparator)) {
+		if info, err := os.Stat(result.Command); err == nil && info.Mode().IsRegular() {
+			if err := os.Chmod(result.Command, info.Mode().Perm()|0755); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
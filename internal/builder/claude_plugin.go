@@ -0,0 +1,218 @@
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ClaudePlugin is a Claude Code plugin's .claude-plugin/plugin.json
+type ClaudePlugin struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// MCPServers is the path of a .mcp.json-style file, or the servers
+	// inline. Without it the plugin's .mcp.json is used.
+	MCPServers json.RawMessage `json:"mcpServers"`
+}
+
+// ClaudePluginServer is a server in a plugin's .mcp.json. Values may use
+// ${CLAUDE_PLUGIN_ROOT} and ${VAR} or ${VAR:-default} from the environment.
+type ClaudePluginServer struct {
+	Type    string            `json:"type"` // "stdio" (default), "http" or "sse"
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+}
+
+// pluginVarRe matches Claude Code's ${VAR} and ${VAR:-default}
+var pluginVarRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// readClaudePlugin reads the plugin in dir and the MCP servers it bundles
+func readClaudePlugin(dir string) (*ClaudePlugin, map[string]ClaudePluginServer, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ".claude-plugin", "plugin.json"))
+	if err != nil {
+		return nil, nil, err
+	}
+	var p ClaudePlugin
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, nil, fmt.Errorf("invalid plugin.json: %w", err)
+	}
+	if p.Name == "" {
+		p.Name = filepath.Base(dir)
+	}
+
+	serversJSON := []byte(p.MCPServers)
+	var ref string
+	if json.Unmarshal(p.MCPServers, &ref) == nil || len(p.MCPServers) == 0 {
+		if ref == "" {
+			ref = ".mcp.json"
+		}
+		path := filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(ref, "${CLAUDE_PLUGIN_ROOT}", ".")))
+		if serversJSON, err = os.ReadFile(path); err != nil {
+			if os.IsNotExist(err) {
+				return &p, nil, nil
+			}
+			return nil, nil, err
+		}
+	}
+
+	// Files wrap the servers in "mcpServers", plugin.json has them directly
+	var wrapped struct {
+		MCPServers map[string]ClaudePluginServer `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(serversJSON, &wrapped); err == nil && wrapped.MCPServers != nil {
+		return &p, wrapped.MCPServers, nil
+	}
+	var servers map[string]ClaudePluginServer
+	if err := json.Unmarshal(serversJSON, &servers); err != nil {
+		return nil, nil, fmt.Errorf("invalid plugin MCP servers: %w", err)
+	}
+	return &p, servers, nil
+}
+
+// buildClaudePlugin returns the servers bundled in a Claude Code plugin,
+// with ${CLAUDE_PLUGIN_ROOT} expanded to the checkout. Servers whose files
+// live in a project that isn't built yet get its BuildDir, built once the
+// user has picked the servers (see PrepareServers).
+func buildClaudePlugin(pluginPath string, plugin *ClaudePlugin, servers map[string]ClaudePluginServer) (*BuildResult, error) {
+	result := &BuildResult{Name: filepath.Base(pluginPath), EnvNeeds: []EnvVar{}}
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	root := strings.NewReplacer("${CLAUDE_PLUGIN_ROOT}", pluginPath)
+	for _, name := range names {
+		s := servers[name]
+		server := &BuildResult{Name: name, EnvNeeds: []EnvVar{}}
+		// Other references to the environment become config settings
+		expand := func(value string) string {
+			return pluginVarRe.ReplaceAllStringFunc(root.Replace(value), func(ref string) string {
+				m := pluginVarRe.FindStringSubmatch(ref)
+				server.addSetting(m[1], m[2])
+				return ConfigPlaceholder(m[1])
+			})
+		}
+
+		switch s.Type {
+		case "http":
+			server.Transport = "http"
+			server.URL = expand(s.URL)
+			if len(s.Headers) > 0 {
+				result.BuildErrors = append(result.BuildErrors, fmt.Errorf("server %s: headers are not registered, add them to the clients yourself", name))
+			}
+		case "", "stdio":
+			if s.Command == "" {
+				return nil, fmt.Errorf("plugin server %s has no command", name)
+			}
+			server.Command = resolveRepoPath(pluginPath, expand(s.Command))
+			for _, arg := range s.Args {
+				server.Args = append(server.Args, resolveRepoPath(pluginPath, expand(arg)))
+			}
+			server.BuildDir = pluginProject(pluginPath, append([]string{server.Command}, server.Args...))
+		default:
+			result.BuildErrors = append(result.BuildErrors, fmt.Errorf("server %s uses %s, which only Claude Code supports, skipped", name, s.Type))
+			continue
+		}
+
+		keys := make([]string, 0, len(s.Env))
+		for key := range s.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := root.Replace(s.Env[key])
+			// "KEY": "${KEY}" is the variable itself, asked for as env
+			if m := pluginVarRe.FindStringSubmatch(value); m != nil && m[0] == value && m[1] == key {
+				server.EnvNeeds = append(server.EnvNeeds, EnvVar{
+					Name:     key,
+					Default:  envOr(key, m[2]),
+					Secret:   looksSecret(key),
+					Optional: m[2] != "",
+				})
+				continue
+			}
+			if server.Env == nil {
+				server.Env = make(map[string]string)
+			}
+			server.Env[key] = expand(value)
+		}
+		result.Servers = append(result.Servers, server)
+	}
+
+	if len(result.Servers) == 0 {
+		return nil, fmt.Errorf("plugin %s bundles no MCP servers mcpm can register", plugin.Name)
+	}
+	return result, nil
+}
+
+// addSetting asks for a config setting, once, defaulting to the
+// environment's value
+func (r *BuildResult) addSetting(name, fallback string) {
+	for _, field := range r.Config {
+		if field.Name == name {
+			return
+		}
+	}
+	r.Config = append(r.Config, EnvVar{
+		Name:        name,
+		Description: "${" + name + "} in the plugin's MCP config",
+		Default:     envOr(name, fallback),
+		Secret:      looksSecret(name),
+		Optional:    fallback != "",
+	})
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// pluginProject returns the project a server's files belong to when it
+// still needs building: the nearest directory above one of the paths,
+// inside the plugin, that a builder recognises, if a path in it is missing
+// or its node dependencies aren't installed. Returns "" otherwise.
+func pluginProject(pluginPath string, paths []string) string {
+	pluginPath = filepath.Clean(pluginPath)
+	for _, p := range paths {
+		// The plugin itself, e.g. uv's --directory ${CLAUDE_PLUGIN_ROOT},
+		// has no project around it to look for
+		if !filepath.IsAbs(p) || !insidePlugin(pluginPath, p) {
+			continue
+		}
+		for dir := filepath.Dir(p); insidePlugin(pluginPath, dir) || dir == pluginPath; dir = filepath.Dir(dir) {
+			switch detectProjectType(dir) {
+			case "", "prebuilt", "container":
+			case "node", "bun":
+				if !exists(p) || !exists(filepath.Join(dir, "node_modules")) {
+					return dir
+				}
+				return ""
+			default:
+				if !exists(p) {
+					return dir
+				}
+				return ""
+			}
+			if dir == pluginPath || filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	return ""
+}
+
+// insidePlugin reports whether p is below pluginPath, not the plugin itself
+func insidePlugin(pluginPath, p string) bool {
+	rel, err := filepath.Rel(pluginPath, p)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
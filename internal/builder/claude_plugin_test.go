@@ -0,0 +1,46 @@
+package builder
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClaudePluginRootArgument(t *testing.T) {
+	// The user's own project around the plugin must not be built
+	parent := t.TempDir()
+	pluginPath := filepath.Join(parent, "weather")
+	writeFiles(t, parent, map[string]string{
+		"package.json":                       `{"name": "mine"}`,
+		"weather/.claude-plugin/plugin.json": `{"name": "weather"}`,
+		"weather/.mcp.json":                  `{"mcpServers": {"weather": {"command": "uv", "args": ["run", "--directory", "${CLAUDE_PLUGIN_ROOT}", "server.py"]}}}`,
+		"weather/pyproject.toml":             "[project]\nname = \"weather\"\n",
+		"weather/server.py":                  "",
+	})
+
+	plugin, servers, err := readClaudePlugin(pluginPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	var result *BuildResult
+	go func() {
+		defer close(done)
+		result, err = buildClaudePlugin(pluginPath, plugin, servers)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("buildClaudePlugin did not return")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := result.Servers[0]
+	if server.BuildDir != "" {
+		t.Errorf("BuildDir = %q, want nothing to build", server.BuildDir)
+	}
+	if server.Args[2] != pluginPath {
+		t.Errorf("args = %v, want the plugin root expanded", server.Args)
+	}
+}
@@ -0,0 +1,91 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ContainerRuntime returns the container CLI to use, docker first
+func ContainerRuntime() (string, error) {
+	for _, rt := range []string{"docker", "podman"} {
+		if commandExists(rt) {
+			return rt, nil
+		}
+	}
+	return "", fmt.Errorf("neither docker nor podman found in PATH")
+}
+
+// ContainerRunArgs returns the arguments to run image as a stdio server,
+// forwarding each of envNames from the client's environment with -e
+func ContainerRunArgs(image string, envNames []string) []string {
+	args := []string{"run", "-i", "--rm"}
+	for _, name := range envNames {
+		args = append(args, "-e", name)
+	}
+	return append(args, image)
+}
+
+// RunArgs returns the arguments to register for the server. For container
+// images, env vars are forwarded into the container with -e flags.
+func (r *BuildResult) RunArgs(env map[string]string) []string {
+	if r.Image == "" {
+		return r.Args
+	}
+	var names []string
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Flags must go before the image, anything after it goes to the server
+	for i, arg := range r.Args {
+		if arg == r.Image {
+			args := append([]string{}, r.Args[:i]...)
+			for _, name := range names {
+				args = append(args, "-e", name)
+			}
+			return append(args, r.Args[i:]...)
+		}
+	}
+	return r.Args
+}
+
+func findDockerfile(path string) string {
+	for _, name := range []string{"Dockerfile", "Containerfile"} {
+		if p := filepath.Join(path, name); exists(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+var invalidImageChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+func buildContainer(ctx context.Context, path string) (*BuildResult, error) {
+	dockerfile := findDockerfile(path)
+	if dockerfile == "" {
+		return nil, fmt.Errorf("no Dockerfile or Containerfile found")
+	}
+	rt, err := ContainerRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.Trim(invalidImageChars.ReplaceAllString(strings.ToLower(filepath.Base(path)), "-"), "-._")
+	image := "mcpm/" + name + ":latest"
+	cmd := fmt.Sprintf("%s build -t %s -f %s .", rt, image, filepath.Base(dockerfile))
+	if err := runShellCmd(ctx, path, cmd); err != nil {
+		return nil, err
+	}
+
+	return &BuildResult{
+		Command:  rt,
+		Args:     ContainerRunArgs(image, nil),
+		EnvNeeds: []EnvVar{},
+		Image:    image,
+	}, nil
+}
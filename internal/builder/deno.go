@@ -0,0 +1,228 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// denoConfig holds the parts of deno.json used to build and run the server
+type denoConfig struct {
+	Exports     interface{}            `json:"exports"`
+	Tasks       map[string]interface{} `json:"tasks"`       // "cmd" or {"command": "cmd"}
+	Permissions map[string]interface{} `json:"permissions"` // Named permission sets, "default" is used
+}
+
+func findDenoConfig(path string) string {
+	for _, name := range []string{"deno.json", "deno.jsonc"} {
+		if p := filepath.Join(path, name); exists(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+func readDenoConfig(configPath string) (denoConfig, error) {
+	var cfg denoConfig
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(stripJSONComments(data), &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid %s: %w", filepath.Base(configPath), err)
+	}
+	return cfg, nil
+}
+
+// task returns the command line of a deno task
+func (c denoConfig) task(name string) string {
+	switch t := c.Tasks[name].(type) {
+	case string:
+		return t
+	case map[string]interface{}:
+		cmd, _ := t["command"].(string)
+		return cmd
+	}
+	return ""
+}
+
+// startTask returns the first task that looks like it starts the server
+func (c denoConfig) startTask() string {
+	for _, name := range []string{"start", "serve", "dev"} {
+		if cmd := c.task(name); strings.Contains(cmd, "deno run") {
+			return cmd
+		}
+	}
+	return ""
+}
+
+// permissionFlags converts the "default" permission set to --allow-* flags
+func (c denoConfig) permissionFlags() []string {
+	set, ok := c.Permissions["default"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var flags []string
+	for _, name := range names {
+		value := set[name]
+		// {"allow": ..., "deny": ...} form
+		if m, ok := value.(map[string]interface{}); ok {
+			value = m["allow"]
+		}
+		switch v := value.(type) {
+		case bool:
+			if v {
+				flags = append(flags, "--allow-"+name)
+			}
+		case []interface{}:
+			var items []string
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					items = append(items, s)
+				}
+			}
+			if len(items) > 0 {
+				flags = append(flags, fmt.Sprintf("--allow-%s=%s", name, strings.Join(items, ",")))
+			}
+		}
+	}
+	return flags
+}
+
+// parseDenoRun extracts permission flags and the entry file from a
+// "deno run ..." command line
+func parseDenoRun(cmdline string) (flags []string, entry string) {
+	fields := strings.Fields(cmdline)
+	for i, f := range fields {
+		if f != "run" || i == 0 || fields[i-1] != "deno" {
+			continue
+		}
+		for _, arg := range fields[i+1:] {
+			if strings.HasPrefix(arg, "-") {
+				if arg == "-A" || strings.HasPrefix(arg, "--allow-") {
+					flags = append(flags, arg)
+				}
+				continue
+			}
+			entry = arg
+			break
+		}
+		break
+	}
+	return flags, entry
+}
+
+func buildDeno(ctx context.Context, path string, m *Manifest) (*BuildResult, error) {
+	var cfg denoConfig
+	if configPath := findDenoConfig(path); configPath != "" {
+		var err error
+		if cfg, err = readDenoConfig(configPath); err != nil {
+			return nil, err
+		}
+	}
+	taskFlags, taskEntry := parseDenoRun(cfg.startTask())
+
+	// Entry: manifest, exports, start task, then well-known files
+	var entry string
+	if m != nil && m.Entry != "" {
+		entry = m.Entry
+	} else if e := exportsEntry(cfg.Exports); e != "" {
+		entry = e
+	} else if taskEntry != "" {
+		entry = taskEntry
+	} else {
+		for _, c := range []string{"main.ts", "mod.ts", "server.ts", "src/main.ts", "src/index.ts", "index.ts"} {
+			if exists(filepath.Join(path, c)) {
+				entry = c
+				break
+			}
+		}
+	}
+	if entry == "" {
+		return nil, fmt.Errorf("could not auto-detect deno entry point (no exports in deno.json, start task, or main.ts)")
+	}
+	absEntry := filepath.Join(path, filepath.FromSlash(entry))
+	if !exists(absEntry) {
+		return nil, fmt.Errorf("deno entry point %s does not exist", entry)
+	}
+
+	// Permissions: manifest, deno.json permission set, then start task flags
+	var flags []string
+	switch {
+	case m != nil && len(m.Permissions) > 0:
+		flags = m.Permissions
+	case len(cfg.permissionFlags()) > 0:
+		flags = cfg.permissionFlags()
+	default:
+		flags = taskFlags
+	}
+
+	if cfg.task("build") != "" {
+		if err := runShellCmd(ctx, path, "deno task build"); err != nil {
+			return nil, err
+		}
+	}
+	// Download dependencies now rather than on first start
+	if err := runShellCmd(ctx, path, "deno cache "+absEntry); err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"run"}, flags...)
+	return &BuildResult{
+		Command:  "deno",
+		Args:     append(args, absEntry),
+		EnvNeeds: []EnvVar{},
+	}, nil
+}
+
+// stripJSONComments removes // and /* */ comments (as allowed in .jsonc)
+// outside of string literals
+func stripJSONComments(data []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
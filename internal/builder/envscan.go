@@ -0,0 +1,256 @@
+package builder
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxSuggestedEnv caps how many discovered variables are offered, so a
+// large codebase doesn't turn the form into a wall of inputs
+const maxSuggestedEnv = 20
+
+// envExampleFiles document the env vars a project reads
+var envExampleFiles = []string{".env.example", ".env.sample", ".env.template", "example.env"}
+
+// envReadPatterns find env var reads in source files, by extension
+var envReadPatterns = map[string][]*regexp.Regexp{
+	"js": {
+		regexp.MustCompile(`process\.env\.([A-Za-z_][A-Za-z0-9_]*)`),
+		regexp.MustCompile(`process\.env\[\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]\s*\]`),
+		regexp.MustCompile(`(?:Deno|Bun)\.env\.get\(\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),
+		regexp.MustCompile(`Bun\.env\.([A-Za-z_][A-Za-z0-9_]*)`),
+	},
+	"py": {
+		regexp.MustCompile(`os\.environ\[\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]\s*\]`),
+		regexp.MustCompile(`os\.environ\.get\(\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),
+		regexp.MustCompile(`os\.getenv\(\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),
+	},
+	"go": {
+		regexp.MustCompile(`os\.(?:Getenv|LookupEnv)\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),
+	},
+	"rs": {
+		regexp.MustCompile(`env::var(?:_os)?\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),
+	},
+	"jvm": {
+		regexp.MustCompile(`System\.getenv\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),
+	},
+}
+
+var envSourceKinds = map[string]string{
+	".js": "js", ".mjs": "js", ".cjs": "js", ".jsx": "js",
+	".ts": "js", ".mts": "js", ".cts": "js", ".tsx": "js",
+	".py": "py", ".go": "go", ".rs": "rs", ".java": "jvm", ".kt": "jvm",
+}
+
+// envScanSkipDirs are dependency and test directories
+var envScanSkipDirs = map[string]bool{
+	".git": true, "node_modules": true, ".venv": true, "venv": true, "__pycache__": true, "vendor": true,
+	"test": true, "tests": true, "__tests__": true, "testdata": true, "examples": true,
+}
+
+// envBuildOutputDirs hold build outputs, scanned only when there is no
+// source elsewhere, e.g. in a published npm package that ships just dist/
+var envBuildOutputDirs = map[string]bool{
+	"dist": true, "build": true, "target": true, "bin": true,
+}
+
+// systemEnv are variables set by the OS, shell or runtime, not the user
+var systemEnv = map[string]bool{
+	"PATH": true, "HOME": true, "USER": true, "USERNAME": true, "USERPROFILE": true, "PWD": true,
+	"SHELL": true, "TERM": true, "LANG": true, "LC_ALL": true, "TZ": true, "HOSTNAME": true,
+	"TMPDIR": true, "TEMP": true, "TMP": true, "APPDATA": true, "LOCALAPPDATA": true,
+	"NODE_ENV": true, "NODE_OPTIONS": true, "PYTHONPATH": true, "VIRTUAL_ENV": true,
+	"GOPATH": true, "GOOS": true, "GOARCH": true, "CI": true, "DEBUG": true,
+}
+
+var (
+	readmeEnvBlockRe = regexp.MustCompile(`"env"\s*:\s*\{([^}]*)\}`)
+	readmeEnvNameRe  = regexp.MustCompile(`"([A-Z_][A-Z0-9_]*)"\s*:`)
+	envExampleLineRe = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
+)
+
+// discoverEnv suggests env vars the server reads, from example env files,
+// the README's MCP config snippets and reads in the source, in that order.
+// Names in known are left out.
+func discoverEnv(repoPath string, known map[string]bool) []EnvVar {
+	return suggestEnv(repoPath, known, scanEnvReads(repoPath))
+}
+
+// discoverPackageEnv is discoverEnv for an installed package, which ships
+// its build output, e.g. dist/, rather than its source
+func discoverPackageEnv(pkgDir string) []EnvVar {
+	reads, _ := scanEnvSources(pkgDir, false)
+	return suggestEnv(pkgDir, nil, reads)
+}
+
+// suggestEnv merges the example env files and README of repoPath with the
+// env var reads found in its code
+func suggestEnv(repoPath string, known map[string]bool, reads []EnvVar) []EnvVar {
+	var found []EnvVar
+	seen := make(map[string]bool)
+	add := func(e EnvVar) {
+		if seen[e.Name] || known[e.Name] || systemEnv[e.Name] || strings.HasPrefix(e.Name, "XDG_") || strings.HasPrefix(e.Name, "npm_") {
+			return
+		}
+		seen[e.Name] = true
+		e.Optional = true
+		e.Suggested = true
+		e.Secret = looksSecret(e.Name)
+		found = append(found, e)
+	}
+
+	for _, name := range envExampleFiles {
+		for _, e := range readEnvExample(filepath.Join(repoPath, name)) {
+			add(e)
+		}
+	}
+	for _, name := range readmeEnvNames(repoPath) {
+		add(EnvVar{Name: name, Description: "suggested, found in README"})
+	}
+	for _, e := range reads {
+		add(e)
+	}
+
+	if len(found) > maxSuggestedEnv {
+		found = found[:maxSuggestedEnv]
+	}
+	return found
+}
+
+// readEnvExample parses NAME=value lines, using trailing or preceding
+// comments as descriptions. Placeholder values aren't used as defaults.
+func readEnvExample(path string) []EnvVar {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var vars []EnvVar
+	var comment string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			comment = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+		m := envExampleLineRe.FindStringSubmatch(line)
+		if m == nil {
+			comment = ""
+			continue
+		}
+		value := m[2]
+		if i := strings.Index(value, " #"); i >= 0 {
+			comment = strings.TrimSpace(value[i+2:])
+			value = value[:i]
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		e := EnvVar{Name: m[1], Description: "suggested, found in " + filepath.Base(path)}
+		if comment != "" {
+			e.Description = comment + " (" + e.Description + ")"
+		}
+		if !looksSecret(e.Name) && !looksPlaceholder(value) {
+			e.Default = value
+		}
+		vars = append(vars, e)
+		comment = ""
+	}
+	return vars
+}
+
+// looksPlaceholder reports whether an example value stands in for a real one
+func looksPlaceholder(value string) bool {
+	lower := strings.ToLower(value)
+	for _, word := range []string{"your", "<", "xxx", "...", "changeme", "replace", "here"} {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// readmeEnvNames returns the names in "env" blocks of the README's client
+// config snippets, e.g. "env": {"API_KEY": "..."}
+func readmeEnvNames(repoPath string) []string {
+	for _, name := range []string{"README.md", "README.MD", "Readme.md", "readme.md", "README"} {
+		data, err := os.ReadFile(filepath.Join(repoPath, name))
+		if err != nil {
+			continue
+		}
+		var names []string
+		for _, block := range readmeEnvBlockRe.FindAllStringSubmatch(string(data), -1) {
+			for _, m := range readmeEnvNameRe.FindAllStringSubmatch(block[1], -1) {
+				names = append(names, m[1])
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// scanEnvReads finds env var reads in the repo's source files, skipping
+// dependencies and tests. Build outputs are only read when the repo has no
+// other source.
+func scanEnvReads(repoPath string) []EnvVar {
+	vars, sources := scanEnvSources(repoPath, true)
+	if sources == 0 {
+		vars, _ = scanEnvSources(repoPath, false)
+	}
+	return vars
+}
+
+// scanEnvSources returns the env var reads in the source files under
+// repoPath and how many source files it read
+func scanEnvSources(repoPath string, skipOutputs bool) (vars []EnvVar, sources int) {
+	// WalkDir doesn't follow a symlinked root, e.g. an npm file: dependency
+	if resolved, err := filepath.EvalSymlinks(repoPath); err == nil {
+		repoPath = resolved
+	}
+	seen := make(map[string]bool)
+	filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != repoPath && (envScanSkipDirs[name] || (skipOutputs && envBuildOutputDirs[name]) || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		kind := envSourceKinds[filepath.Ext(path)]
+		if kind == "" || isTestFile(d.Name()) {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > 1<<20 {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		sources++
+		rel, _ := filepath.Rel(repoPath, path)
+		for _, re := range envReadPatterns[kind] {
+			for _, m := range re.FindAllStringSubmatch(string(data), -1) {
+				if !seen[m[1]] {
+					seen[m[1]] = true
+					vars = append(vars, EnvVar{Name: m[1], Description: "suggested, read in " + filepath.ToSlash(rel)})
+				}
+			}
+		}
+		return nil
+	})
+	return vars, sources
+}
+
+func isTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go") || strings.HasPrefix(name, "test_") ||
+		strings.Contains(name, ".test.") || strings.Contains(name, ".spec.")
+}
@@ -0,0 +1,66 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func envNames(vars []EnvVar) []string {
+	names := []string{}
+	for _, e := range vars {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+func TestDiscoverEnvSkipsBuildOutputNextToSource(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"src/index.ts":                   "const key = process.env.WEATHER_API_KEY",
+		"dist/index.js":                  "const key = process.env.WEATHER_API_KEY; process.env.BUNDLED_ONLY",
+		"node_modules/dep/index.js":      "process.env.DEP_VAR",
+		"src/__tests__/index.test.ts":    "process.env.TEST_VAR",
+		"scripts/release.test.js":        "process.env.RELEASE_VAR",
+		".github/workflows/build/run.js": "process.env.CI_VAR",
+	})
+	got := envNames(discoverEnv(dir, nil))
+	if len(got) != 1 || got[0] != "WEATHER_API_KEY" {
+		t.Errorf("discovered %v, want [WEATHER_API_KEY]", got)
+	}
+}
+
+func TestDiscoverEnvReadsDistOnlyPackage(t *testing.T) {
+	// A published npm package ships its build output and no source
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json":              `{"name": "weather-mcp", "bin": "dist/index.js"}`,
+		"dist/index.js":             "const key = process.env.WEATHER_API_KEY",
+		"dist/lib/client.js":        "const url = process.env['WEATHER_URL']",
+		"node_modules/dep/index.js": "process.env.DEP_VAR",
+	})
+	got := envNames(discoverEnv(dir, nil))
+	if len(got) != 2 || got[0] != "WEATHER_API_KEY" || got[1] != "WEATHER_URL" {
+		t.Errorf("discoverEnv found %v, want [WEATHER_API_KEY WEATHER_URL]", got)
+	}
+
+	// A launcher next to dist/ is not the package's source
+	writeFiles(t, dir, map[string]string{"cli.js": "require('./dist/index.js')"})
+	got = envNames(discoverPackageEnv(dir))
+	if len(got) != 2 || got[0] != "WEATHER_API_KEY" || got[1] != "WEATHER_URL" {
+		t.Errorf("discoverPackageEnv found %v, want [WEATHER_API_KEY WEATHER_URL]", got)
+	}
+}
@@ -0,0 +1,176 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GeminiExtension is a Gemini CLI extension's gemini-extension.json
+type GeminiExtension struct {
+	Name       string                           `json:"name"`
+	Version    string                           `json:"version"`
+	MCPServers map[string]GeminiExtensionServer `json:"mcpServers"`
+	Settings   []GeminiExtensionSetting         `json:"settings"`
+}
+
+// GeminiExtensionServer is one entry of mcpServers. Values may use
+// ${extensionPath}, ${workspacePath} and ${/}.
+type GeminiExtensionServer struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+	Cwd     string            `json:"cwd"`
+	URL     string            `json:"url"`     // SSE endpoint
+	HTTPURL string            `json:"httpUrl"` // Streamable HTTP endpoint
+}
+
+// GeminiExtensionSetting is a value the extension asks for on install,
+// passed to its servers as an env var
+type GeminiExtensionSetting struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	EnvVar      string `json:"envVar"`
+	Sensitive   bool   `json:"sensitive"`
+}
+
+// extensionNameRe is what an extension name may be: it becomes a directory
+// name under the extensions directory
+var extensionNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ValidExtensionName reports whether name is a single plain path component
+func ValidExtensionName(name string) bool {
+	return extensionNameRe.MatchString(name) && name != "." && name != ".."
+}
+
+// envRefRe matches an env value that is just a reference to another
+// variable, e.g. "$API_KEY" or "${API_KEY}"
+var envRefRe = regexp.MustCompile(`^\$\{?([A-Za-z_][A-Za-z0-9_]*)\}?$`)
+
+// ReadGeminiExtension reads the gemini-extension.json in dir
+func ReadGeminiExtension(dir string) (*GeminiExtension, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "gemini-extension.json"))
+	if err != nil {
+		return nil, err
+	}
+	var ext GeminiExtension
+	if err := json.Unmarshal(data, &ext); err != nil {
+		return nil, fmt.Errorf("invalid gemini-extension.json: %w", err)
+	}
+	if ext.Name == "" {
+		return nil, fmt.Errorf("gemini-extension.json has no name")
+	}
+	if !ValidExtensionName(ext.Name) {
+		return nil, fmt.Errorf("gemini-extension.json name %q may only use letters, digits, '.', '_' and '-'", ext.Name)
+	}
+	return &ext, nil
+}
+
+// buildGeminiExtension installs an extension's node dependencies, if it has
+// any, and returns its servers for the other clients, with the extension
+// variables expanded to the checkout. Gemini CLI installs the extension
+// itself (see BuildResult.GeminiExtension).
+func buildGeminiExtension(ctx context.Context, extPath string, ext *GeminiExtension) (*BuildResult, error) {
+	if exists(filepath.Join(extPath, "package.json")) {
+		if _, err := installNodeDeps(ctx, extPath); err != nil {
+			return nil, err
+		}
+	}
+
+	cwd, _ := os.Getwd()
+	sep := string(os.PathSeparator)
+	expand := strings.NewReplacer(
+		"${extensionPath}", extPath,
+		"${workspacePath}", cwd,
+		"${/}", sep,
+		"${pathSeparator}", sep,
+	)
+
+	var settings []EnvVar
+	for _, s := range ext.Settings {
+		if s.EnvVar == "" {
+			continue
+		}
+		e := EnvVar{Name: s.EnvVar, Description: s.Description, Secret: s.Sensitive || looksSecret(s.EnvVar)}
+		if e.Description == "" {
+			e.Description = s.Name
+		}
+		settings = append(settings, e)
+	}
+
+	result := &BuildResult{
+		Name:            filepath.Base(extPath),
+		EnvNeeds:        []EnvVar{},
+		GeminiExtension: extPath,
+	}
+	names := make([]string, 0, len(ext.MCPServers))
+	for name := range ext.MCPServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s := ext.MCPServers[name]
+		server := &BuildResult{Name: name, EnvNeeds: append([]EnvVar{}, settings...)}
+		switch {
+		case s.HTTPURL != "":
+			server.Transport = "http"
+			server.URL = expand.Replace(s.HTTPURL)
+		case s.Command != "":
+			dir := extPath
+			if s.Cwd != "" {
+				dir = expand.Replace(s.Cwd)
+			}
+			server.Command = resolveRepoPath(dir, expand.Replace(s.Command))
+			for _, arg := range s.Args {
+				server.Args = append(server.Args, resolveRepoPath(dir, expand.Replace(arg)))
+			}
+		default:
+			// Only Gemini CLI itself gets SSE servers
+			result.BuildErrors = append(result.BuildErrors, fmt.Errorf("server %s has no command or httpUrl, skipped for other clients", name))
+			continue
+		}
+
+		keys := make([]string, 0, len(s.Env))
+		for key := range s.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := s.Env[key]
+			// A reference to the user's environment is asked for instead
+			if m := envRefRe.FindStringSubmatch(value); m != nil {
+				if !hasEnvVar(server.EnvNeeds, key) {
+					server.EnvNeeds = append(server.EnvNeeds, EnvVar{
+						Name:        key,
+						Description: "from $" + m[1] + " in gemini-extension.json",
+						Default:     os.Getenv(m[1]),
+						Secret:      looksSecret(key),
+						Optional:    true,
+					})
+				}
+				continue
+			}
+			if server.Env == nil {
+				server.Env = make(map[string]string)
+			}
+			server.Env[key] = expand.Replace(value)
+		}
+		result.Servers = append(result.Servers, server)
+	}
+	return result, nil
+}
+
+func hasEnvVar(vars []EnvVar, name string) bool {
+	for _, e := range vars {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
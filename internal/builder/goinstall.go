@@ -0,0 +1,76 @@
+package builder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+var goMajorVersionRe = regexp.MustCompile(`^v[0-9]+$`)
+
+// goBinaryName is the name go install gives the binary of a package: its
+// last path element, skipping a major version suffix like /v2
+func goBinaryName(pkgPath string) string {
+	name := path.Base(pkgPath)
+	if goMajorVersionRe.MatchString(name) && strings.Contains(pkgPath, "/") {
+		name = path.Base(path.Dir(pkgPath))
+	}
+	return name
+}
+
+// installGoModule runs go install for a main package with GOBIN set to dir.
+// version is a module query such as v1.2.3, empty for latest. Returns the
+// installed module version.
+func installGoModule(ctx context.Context, dir, pkgPath, version string) (*BuildResult, string, error) {
+	if !commandExists("go") {
+		return nil, "", fmt.Errorf("go is required to install %s", pkgPath)
+	}
+	if version == "" {
+		version = "latest"
+	}
+
+	flags, env := goBuildSettings()
+	env = append(env, "GOBIN="+dir)
+	cmd := fmt.Sprintf("go install%s %s@%s", flags, pkgPath, version)
+	if err := runShellCmd(ctx, dir, cmd, env...); err != nil {
+		return nil, "", err
+	}
+
+	bin := filepath.Join(dir, goBinaryName(pkgPath))
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	if !exists(bin) {
+		return nil, "", fmt.Errorf("go install did not produce %s", bin)
+	}
+
+	resolved, err := goModuleVersion(ctx, bin)
+	if err != nil {
+		return nil, "", err
+	}
+	return &BuildResult{Command: bin, EnvNeeds: []EnvVar{}}, resolved, nil
+}
+
+// goModuleVersion reads the main module's version from a binary's build info
+func goModuleVersion(ctx context.Context, bin string) (string, error) {
+	out, err := exec.CommandContext(ctx, "go", "version", "-m", bin).Output()
+	if err != nil {
+		return "", fmt.Errorf("could not read the build info of %s: %w", bin, err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// "\tmod\t<module path>\t<version>\t<sum>"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 3 && fields[0] == "mod" {
+			return fields[2], nil
+		}
+	}
+	return "", fmt.Errorf("no module version in the build info of %s", bin)
+}
@@ -1,25 +1,214 @@
 package builder
 
 import (
+	"context"
 	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
+
+	"mcpm/internal/config"
 )
 
-func buildGo(path string) (*BuildResult, error) {
-	binName := "mcp-server"
-	if runtime.GOOS == "windows" {
-		binName = "mcp-server.exe"
+// findGoMainPackages returns the directories (relative, slash-separated,
+// "." for the root) of the module at root that contain a main package
+func findGoMainPackages(root string) []string {
+	var dirs []string
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if p != root {
+			// Same rules as the go tool, plus nested modules
+			if name == "vendor" || name == "testdata" || name == "node_modules" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+				exists(filepath.Join(p, "go.mod")) {
+				return filepath.SkipDir
+			}
+		}
+		if isGoMainPackage(p) {
+			rel, _ := filepath.Rel(root, p)
+			dirs = append(dirs, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return dirs
+}
+
+func isGoMainPackage(dir string) bool {
+	entries, _ := os.ReadDir(dir)
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.PackageClauseOnly)
+		if err == nil {
+			return f.Name.Name == "main"
+		}
 	}
+	return false
+}
 
-	cmd := fmt.Sprintf("go build -o %s .", binName)
-	if err := runShellCmd(path, cmd); err != nil {
-		return nil, err
+// goBuildSettings returns the go build flags and environment configured in
+// ~/.mcpm.yaml
+func goBuildSettings() (flags string, env []string) {
+	if config.GoTrimpath() {
+		flags += " -trimpath"
 	}
+	if tags := config.GoTags(); len(tags) > 0 {
+		flags += " -tags " + strings.Join(tags, ",")
+	}
+	if cgo := config.GoCGOEnabled(); cgo != "" {
+		env = append(env, "CGO_ENABLED="+cgo)
+	}
+	return flags, env
+}
+
+// pickGoMainPackage chooses the server's main package: cmd/*mcp*, then the
+// module root, then any single *mcp* package or the only main package.
+// Returns nil when the choice is ambiguous.
+func pickGoMainPackage(dirs []string) []string {
+	filter := func(keep func(dir string) bool) []string {
+		var out []string
+		for _, dir := range dirs {
+			if keep(dir) {
+				out = append(out, dir)
+			}
+		}
+		return out
+	}
+	isMCP := func(dir string) bool { return strings.Contains(strings.ToLower(path.Base(dir)), "mcp") }
+
+	if cmdMCP := filter(func(dir string) bool { return strings.HasPrefix(dir, "cmd/") && isMCP(dir) }); len(cmdMCP) == 1 {
+		return cmdMCP
+	}
+	if root := filter(func(dir string) bool { return dir == "." }); len(root) == 1 {
+		return root
+	}
+	if anyMCP := filter(isMCP); len(anyMCP) == 1 {
+		return anyMCP
+	}
+	if len(dirs) == 1 {
+		return dirs
+	}
+	return nil
+}
 
-	return &BuildResult{
-		Command:  filepath.Join(path, binName),
-		Args:     []string{},
-		EnvNeeds: []string{},
-	}, nil
+// goBinaryNames names the binary of each main package: after the server
+// when there is one, so it is identifiable in ps, or else after the
+// package's directory. Packages whose directories share a name get more of
+// their path, e.g. cmd/a/server and cmd/b/server build a-server and
+// b-server, and a number when the whole path still clashes.
+func goBinaryNames(serverName string, pkgs []string) map[string]string {
+	names := make(map[string]string)
+	if len(pkgs) == 1 {
+		names[pkgs[0]] = serverName
+		return names
+	}
+	depth := make(map[string]int)
+	suffix := make(map[string]int)
+	name := func(pkg string) string {
+		n := serverName
+		if pkg != "." {
+			parts := strings.Split(pkg, "/")
+			n = strings.Join(parts[len(parts)-depth[pkg]:], "-")
+		}
+		if suffix[pkg] > 0 {
+			n = fmt.Sprintf("%s-%d", n, suffix[pkg])
+		}
+		return n
+	}
+	for _, pkg := range pkgs {
+		depth[pkg] = 1
+	}
+	for {
+		byName := make(map[string][]string)
+		for _, pkg := range pkgs {
+			byName[name(pkg)] = append(byName[name(pkg)], pkg)
+		}
+		progress, clash := false, []string(nil)
+		for _, same := range byName {
+			if len(same) < 2 {
+				continue
+			}
+			clash = same
+			for _, pkg := range same {
+				if pkg != "." && depth[pkg] < len(strings.Split(pkg, "/")) {
+					depth[pkg]++
+					progress = true
+				}
+			}
+		}
+		if clash == nil {
+			break
+		}
+		if !progress {
+			// e.g. the root package and <repo>/, or a-b/c and a/b-c. The
+			// first keeps the name, the root one when it clashes.
+			sort.Strings(clash)
+			for i, pkg := range clash[1:] {
+				suffix[pkg] += i + 2
+			}
+		}
+	}
+	for _, pkg := range pkgs {
+		names[pkg] = name(pkg)
+	}
+	return names
+}
+
+func buildGo(ctx context.Context, repoPath string, m *Manifest) (*BuildResult, error) {
+	serverName := filepath.Base(repoPath)
+
+	var pkgs []string
+	if m != nil && m.Entry != "" {
+		pkgs = []string{strings.TrimPrefix(filepath.ToSlash(m.Entry), "./")}
+	} else {
+		found := findGoMainPackages(repoPath)
+		if len(found) == 0 {
+			return nil, fmt.Errorf("no main package found in %s", repoPath)
+		}
+		if pkgs = pickGoMainPackage(found); pkgs == nil {
+			// Can't tell which one is the server: build them all and let the user pick
+			pkgs = found
+			sortByMCP(pkgs)
+		}
+	}
+
+	binNames := goBinaryNames(serverName, pkgs)
+	flags, env := goBuildSettings()
+	var candidates []Candidate
+	for _, pkg := range pkgs {
+		binName := binNames[pkg]
+		if runtime.GOOS == "windows" {
+			binName += ".exe"
+		}
+		binPath := filepath.Join("bin", binName)
+
+		cmd := fmt.Sprintf("go build%s -o %s ./%s", flags, binPath, strings.TrimPrefix(pkg, "."))
+		if err := runShellCmd(ctx, repoPath, cmd, env...); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, Candidate{
+			Label:   "package: ./" + strings.TrimPrefix(pkg, "."),
+			Command: filepath.Join(repoPath, binPath),
+			Args:    []string{},
+		})
+	}
+
+	result := &BuildResult{EnvNeeds: []EnvVar{}}
+	result.Use(candidates[0])
+	if len(candidates) > 1 {
+		result.Candidates = candidates
+	}
+	return result, nil
 }
@@ -0,0 +1,34 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGoBinaryNames(t *testing.T) {
+	tests := []struct {
+		pkgs []string
+		want map[string]string
+	}{
+		{[]string{"cmd/server"}, map[string]string{"cmd/server": "weather"}},
+		{[]string{".", "cmd/cli"}, map[string]string{".": "weather", "cmd/cli": "cli"}},
+		{[]string{"cmd/a/server", "cmd/b/server", "cmd/tool"}, map[string]string{
+			"cmd/a/server": "a-server", "cmd/b/server": "b-server", "cmd/tool": "tool",
+		}},
+		// The root package keeps the server's name, the other one moves
+		{[]string{".", "cmd/weather"}, map[string]string{".": "weather", "cmd/weather": "cmd-weather"}},
+		{[]string{"x/cmd/server", "y/cmd/server"}, map[string]string{
+			"x/cmd/server": "x-cmd-server", "y/cmd/server": "y-cmd-server",
+		}},
+		// Whole paths that still clash get a number
+		{[]string{".", "weather"}, map[string]string{".": "weather", "weather": "weather-2"}},
+		{[]string{"a-b/c", "a/b-c", "b-c", "c"}, map[string]string{
+			"a-b/c": "a-b-c", "a/b-c": "a-b-c-2", "b-c": "b-c", "c": "c",
+		}},
+	}
+	for _, tc := range tests {
+		if got := goBinaryNames("weather", tc.pkgs); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%v: names %v, want %v", tc.pkgs, got, tc.want)
+		}
+	}
+}
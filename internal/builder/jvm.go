@@ -0,0 +1,115 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+func isMavenProject(path string) bool {
+	return exists(filepath.Join(path, "pom.xml"))
+}
+
+func gradleBuildFile(path string) string {
+	for _, name := range []string{"build.gradle.kts", "build.gradle"} {
+		if p := filepath.Join(path, name); exists(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// wrapperOr returns the project's wrapper script (mvnw, gradlew) when present,
+// so the build uses the tool version the project expects
+func wrapperOr(path, wrapper, tool string) string {
+	if runtime.GOOS == "windows" {
+		for _, ext := range []string{".cmd", ".bat"} {
+			if exists(filepath.Join(path, wrapper+ext)) {
+				return wrapper + ext
+			}
+		}
+		return tool
+	}
+	script := filepath.Join(path, wrapper)
+	if !exists(script) {
+		return tool
+	}
+	// Archives and some checkouts lose the executable bit
+	os.Chmod(script, 0755)
+	return "./" + wrapper
+}
+
+func buildJVM(ctx context.Context, path string) (*BuildResult, error) {
+	var jarDir string
+	if isMavenProject(path) {
+		mvn := wrapperOr(path, "mvnw", "mvn")
+		if err := runShellCmd(ctx, path, mvn+" -B -DskipTests package"); err != nil {
+			return nil, err
+		}
+		jarDir = "target"
+	} else {
+		gradle := wrapperOr(path, "gradlew", "gradle")
+		task := "build -x test"
+		// The shadow plugin builds the fat jar in its own task
+		if data, err := os.ReadFile(gradleBuildFile(path)); err == nil && strings.Contains(string(data), "shadow") {
+			task = "shadowJar"
+		}
+		if err := runShellCmd(ctx, path, gradle+" --no-daemon "+task); err != nil {
+			return nil, err
+		}
+		jarDir = filepath.Join("build", "libs")
+	}
+
+	jar := findFatJar(path, jarDir)
+	if jar == "" {
+		return nil, fmt.Errorf("build succeeded but no runnable jar was found in %s", jarDir)
+	}
+
+	return &BuildResult{
+		Command:  "java",
+		Args:     []string{"-jar", jar},
+		EnvNeeds: []EnvVar{},
+	}, nil
+}
+
+// findFatJar looks for the jar to run in <path>/<jarDir> and in the same
+// directory of each sub-module. Shaded/fat jars win, then the largest jar.
+func findFatJar(path, jarDir string) string {
+	jars, _ := filepath.Glob(filepath.Join(path, jarDir, "*.jar"))
+	moduleJars, _ := filepath.Glob(filepath.Join(path, "*", jarDir, "*.jar"))
+	jars = append(jars, moduleJars...)
+
+	var best string
+	var bestFat bool
+	var bestSize int64
+	for _, jar := range jars {
+		name := strings.ToLower(filepath.Base(jar))
+		// Skip jars that can't be run, and the pre-shading original
+		if strings.HasPrefix(name, "original-") ||
+			strings.HasSuffix(name, "-sources.jar") ||
+			strings.HasSuffix(name, "-javadoc.jar") ||
+			strings.HasSuffix(name, "-tests.jar") ||
+			strings.HasSuffix(name, "-plain.jar") {
+			continue
+		}
+		info, err := os.Stat(jar)
+		if err != nil {
+			continue
+		}
+
+		fat := false
+		for _, marker := range []string{"-all", "-shaded", "-jar-with-dependencies", "-fat", "-uber", "-standalone"} {
+			if strings.Contains(name, marker) {
+				fat = true
+				break
+			}
+		}
+		if best == "" || (fat && !bestFat) || (fat == bestFat && info.Size() > bestSize) {
+			best, bestFat, bestSize = jar, fat, info.Size()
+		}
+	}
+	return best
+}
@@ -0,0 +1,230 @@
+package builder
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"mcpm/internal/jsonschema"
+)
+
+// ManifestVersion is the latest mcp.json format version
+const ManifestVersion = 2
+
+// ManifestSchema is the JSON Schema for mcp.json
+//
+//go:embed manifest.schema.json
+var ManifestSchema []byte
+
+// Manifest represents an optional mcp.json file in the repo.
+// Version 1 files (no "version") only use type, buildCmd, runCmd, args and
+// requiredEnv. When runCmd is empty, type selects the builder instead of
+// detection.
+type Manifest struct {
+	Version     int                         `json:"version,omitempty"`     // 2, omitted in version 1
+	Type        string                      `json:"type,omitempty"`        // One of Builders
+	Install     []string                    `json:"install,omitempty"`     // Steps that fetch dependencies
+	Build       []string                    `json:"build,omitempty"`       // Steps that build the server
+	BuildCmd    string                      `json:"buildCmd,omitempty"`    // Version 1 build step, runs after Build
+	RunCmd      string                      `json:"runCmd,omitempty"`      // The command to start it
+	Args        []string                    `json:"args,omitempty"`        // Default args
+	Env         []EnvVar                    `json:"env,omitempty"`         // Variables to ask the user for
+	RequiredEnv []string                    `json:"requiredEnv,omitempty"` // Version 1 variables, names only
+	Transport   string                      `json:"transport,omitempty"`   // "stdio" (default) or "http"
+	Port        int                         `json:"port,omitempty"`        // HTTP port, served at /mcp
+	URL         string                      `json:"url,omitempty"`         // HTTP endpoint, overrides port
+	Entry       string                      `json:"entry,omitempty"`       // Entry file (deno) or main package directory (go), relative to the repo
+	Permissions []string                    `json:"permissions,omitempty"` // Deno permission flags, e.g. "--allow-net"
+	OS          map[string]ManifestOverride `json:"os,omitempty"`          // Per-OS overrides keyed by GOOS
+	Assets      map[string]string           `json:"assets,omitempty"`      // Release asset per "goos/goarch", for install --release
+	Servers     []ManifestServer            `json:"servers,omitempty"`     // Several servers in the repo, instead of runCmd
+}
+
+// ManifestServer is one of several servers in a repo. Install and Build run
+// once for all of them.
+type ManifestServer struct {
+	Name      string   `json:"name"`                // Registered under this name
+	Path      string   `json:"path,omitempty"`      // Its directory, relative to the repo
+	RunCmd    string   `json:"runCmd,omitempty"`    // Without it, Path is built by its detected builder
+	Args      []string `json:"args,omitempty"`      // Paths are relative to Path
+	Env       []EnvVar `json:"env,omitempty"`       // Asked for on top of the manifest's env
+	Transport string   `json:"transport,omitempty"` // "stdio" (default) or "http"
+	Port      int      `json:"port,omitempty"`
+	URL       string   `json:"url,omitempty"`
+}
+
+// ManifestOverride replaces the matching Manifest fields on one OS
+type ManifestOverride struct {
+	Install []string `json:"install,omitempty"`
+	Build   []string `json:"build,omitempty"`
+	RunCmd  string   `json:"runCmd,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	Env     []EnvVar `json:"env,omitempty"`
+}
+
+func loadManifest(manifestPath string) (*Manifest, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid mcp.json: %w", err)
+	}
+	if m.Version > ManifestVersion {
+		return nil, fmt.Errorf("mcp.json version %d is not supported (max %d), please update mcpm", m.Version, ManifestVersion)
+	}
+	if m.Transport == "http" && m.Port == 0 && m.URL == "" {
+		return nil, fmt.Errorf("invalid mcp.json: transport http requires port or url")
+	}
+	for _, s := range m.Servers {
+		if s.Transport == "http" && s.Port == 0 && s.URL == "" {
+			return nil, fmt.Errorf("invalid mcp.json: server %s: transport http requires port or url", s.Name)
+		}
+	}
+	m.applyOverride(runtime.GOOS)
+	return &m, nil
+}
+
+// applyOverride replaces fields with those set for goos
+func (m *Manifest) applyOverride(goos string) {
+	o, ok := m.OS[goos]
+	if !ok {
+		return
+	}
+	if o.Install != nil {
+		m.Install = o.Install
+	}
+	if o.Build != nil {
+		m.Build = o.Build
+	}
+	if o.RunCmd != "" {
+		m.RunCmd = o.RunCmd
+	}
+	if o.Args != nil {
+		m.Args = o.Args
+	}
+	if o.Env != nil {
+		m.Env = o.Env
+	}
+}
+
+// steps returns the shell commands to run, in order
+func (m *Manifest) steps() []string {
+	return append(append([]string{}, m.Install...), m.buildSteps()...)
+}
+
+// buildSteps returns the steps after Install, which run once a type's
+// builder has fetched its dependencies
+func (m *Manifest) buildSteps() []string {
+	steps := append([]string{}, m.Build...)
+	if m.BuildCmd != "" {
+		steps = append(steps, m.BuildCmd)
+	}
+	return steps
+}
+
+// envNeeds merges version 1 requiredEnv names with version 2 env entries
+func (m *Manifest) envNeeds() []EnvVar {
+	needs := []EnvVar{}
+	seen := make(map[string]bool)
+	for _, e := range m.Env {
+		if !seen[e.Name] {
+			seen[e.Name] = true
+			needs = append(needs, e)
+		}
+	}
+	for _, name := range m.RequiredEnv {
+		if !seen[name] {
+			seen[name] = true
+			needs = append(needs, EnvVar{Name: name})
+		}
+	}
+	return needs
+}
+
+// applyTransport sets the HTTP endpoint on result when the server isn't stdio
+func (m *Manifest) applyTransport(result *BuildResult) {
+	if m.Transport != "http" {
+		return
+	}
+	result.Transport = "http"
+	result.URL = m.URL
+	if result.URL == "" {
+		result.URL = fmt.Sprintf("http://localhost:%d/mcp", m.Port)
+	}
+}
+
+// resolveRepoPath makes p absolute when it refers to a file in the repo,
+// and leaves commands on PATH and flags alone
+func resolveRepoPath(repoPath, p string) string {
+	if p == "" || filepath.IsAbs(p) || strings.HasPrefix(p, "-") {
+		return p
+	}
+	if strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../") || exists(filepath.Join(repoPath, p)) {
+		return filepath.Join(repoPath, filepath.FromSlash(p))
+	}
+	return p
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateManifest checks an mcp.json document against ManifestSchema and
+// the rules the schema can't express. It returns every problem found.
+func ValidateManifest(data []byte) []error {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []error{fmt.Errorf("invalid JSON: %w", err)}
+	}
+	schema, err := jsonschema.Parse(ManifestSchema)
+	if err != nil {
+		return []error{fmt.Errorf("invalid manifest schema: %w", err)}
+	}
+	if errs := schema.Validate(doc); len(errs) > 0 {
+		return errs
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return []error{err}
+	}
+	var errs []error
+	if m.Version >= 2 && m.RunCmd == "" && m.Type == "" && len(m.Servers) == 0 {
+		errs = append(errs, fmt.Errorf("either runCmd, type or servers is required"))
+	}
+	if m.Transport == "http" && m.Port == 0 && m.URL == "" {
+		errs = append(errs, fmt.Errorf("transport http requires port or url"))
+	}
+	envs := append([]EnvVar{}, m.Env...)
+	for _, o := range m.OS {
+		envs = append(envs, o.Env...)
+	}
+	names := make(map[string]bool)
+	for _, s := range m.Servers {
+		if names[s.Name] {
+			errs = append(errs, fmt.Errorf("server %s is listed twice", s.Name))
+		}
+		names[s.Name] = true
+		if s.RunCmd == "" && s.Path == "" && s.URL == "" {
+			errs = append(errs, fmt.Errorf("server %s needs runCmd, path or url", s.Name))
+		}
+		if s.Transport == "http" && s.Port == 0 && s.URL == "" {
+			errs = append(errs, fmt.Errorf("server %s: transport http requires port or url", s.Name))
+		}
+		envs = append(envs, s.Env...)
+	}
+	for _, name := range m.RequiredEnv {
+		envs = append(envs, EnvVar{Name: name})
+	}
+	for _, e := range envs {
+		if !envNamePattern.MatchString(e.Name) {
+			errs = append(errs, fmt.Errorf("invalid environment variable name %q", e.Name))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,97 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writeManifest(t *testing.T, dir, manifest string) string {
+	t.Helper()
+	path := filepath.Join(dir, "mcp.json")
+	if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadManifestRequiresHTTPEndpoint(t *testing.T) {
+	tests := []struct {
+		manifest string
+		wantErr  string
+	}{
+		{`{"version": 2, "runCmd": "server", "transport": "http"}`, "transport http requires port or url"},
+		{`{"version": 2, "servers": [{"name": "api", "runCmd": "api", "transport": "http"}]}`, "server api: transport http requires port or url"},
+		{`{"version": 2, "runCmd": "server", "transport": "http", "port": 8080}`, ""},
+		{`{"version": 2, "servers": [{"name": "api", "transport": "http", "url": "https://api.example.com/mcp"}]}`, ""},
+	}
+	for _, tc := range tests {
+		_, err := loadManifest(writeManifest(t, t.TempDir(), tc.manifest))
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("%s: %v", tc.manifest, err)
+		}
+		if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", tc.manifest, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidateManifestAcceptsEveryBuilder(t *testing.T) {
+	for _, name := range Builders {
+		if errs := ValidateManifest([]byte(`{"version": 2, "type": "` + name + `"}`)); len(errs) > 0 {
+			t.Errorf("type %s: %v", name, errs)
+		}
+	}
+}
+
+func TestManifestInstallRunsBeforeBuilder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("steps use a POSIX shell")
+	}
+	dir := t.TempDir()
+	// The prebuilt builder only finds the binary if install ran first, and
+	// build checks the builder has run
+	writeManifest(t, dir, `{
+	  "version": 2,
+	  "type": "prebuilt",
+	  "install": ["mkdir -p bin && printf '\\177ELF' > bin/server"],
+	  "build": ["test -x bin/server && touch built"]
+	}`)
+	result, err := DetectAndBuild(context.Background(), dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "bin", "server"); result.Command != want {
+		t.Errorf("command = %s, want %s", result.Command, want)
+	}
+	if !exists(filepath.Join(dir, "built")) {
+		t.Error("build steps did not run after the builder")
+	}
+}
+
+func TestBuilderOptionOverridesManifestRunCmd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("steps use a POSIX shell")
+	}
+	dir := t.TempDir()
+	writeManifest(t, dir, `{
+	  "version": 2,
+	  "runCmd": "./start.sh",
+	  "install": ["mkdir -p bin && printf '\\177ELF' > bin/server"]
+	}`)
+	result, err := DetectAndBuild(context.Background(), dir, Options{Builder: "prebuilt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "bin", "server"); result.Command != want {
+		t.Errorf("command = %s, want the prebuilt builder's %s", result.Command, want)
+	}
+
+	writeManifest(t, dir, `{"version": 2, "servers": [{"name": "api", "runCmd": "api"}]}`)
+	if _, err := DetectAndBuild(context.Background(), dir, Options{Builder: "prebuilt"}); err == nil {
+		t.Error("--builder was ignored for mcp.json servers")
+	}
+}
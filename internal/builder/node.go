@@ -1,17 +1,36 @@
 package builder
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
 type PackageJSON struct {
+	Name    string            `json:"name"`
+	Version string            `json:"version"`
 	Scripts map[string]string `json:"scripts"`
 	Main    string            `json:"main"`
-	Bin     interface{}       `json:"bin"`
+	Module  string            `json:"module"`
+	Bin     interface{}       `json:"bin"`     // "path" or {"cmd": "path"}
+	Exports interface{}       `json:"exports"` // "path", {".": ...} or {"import": ..., "require": ...}
+}
+
+func readPackageJSON(dir string) (PackageJSON, error) {
+	var pkg PackageJSON
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return pkg, err
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return pkg, fmt.Errorf("invalid package.json: %w", err)
+	}
+	return pkg, nil
 }
 
 func commandExists(cmd string) bool {
@@ -19,6 +38,160 @@ func commandExists(cmd string) bool {
 	return err == nil
 }
 
+// nodeEntry is a file that package.json declares as the way to start the package
+type nodeEntry struct {
+	field string // package.json field it was declared in
+	label string
+	path  string // Relative to the package directory
+}
+
+// declaredNodeEntries returns the entries declared by package.json, from the
+// first field that has any: bin, exports, main, module.
+func declaredNodeEntries(pkg PackageJSON) []nodeEntry {
+	if entries := binEntries(pkg); len(entries) > 0 {
+		return entries
+	}
+	if e := exportsEntry(pkg.Exports); e != "" {
+		return []nodeEntry{{field: "exports", label: "exports: " + e, path: e}}
+	}
+	if pkg.Main != "" {
+		return []nodeEntry{{field: "main", label: "main: " + pkg.Main, path: pkg.Main}}
+	}
+	if pkg.Module != "" {
+		return []nodeEntry{{field: "module", label: "module: " + pkg.Module, path: pkg.Module}}
+	}
+	return nil
+}
+
+// binEntries resolves the bin field. When bin is a map, the commands are
+// narrowed down with preferCommands.
+func binEntries(pkg PackageJSON) []nodeEntry {
+	switch bin := pkg.Bin.(type) {
+	case string:
+		if bin != "" {
+			return []nodeEntry{{field: "bin", label: "bin: " + bin, path: bin}}
+		}
+	case map[string]interface{}:
+		var names []string
+		for name, target := range bin {
+			if _, ok := target.(string); ok {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return nil
+		}
+
+		var entries []nodeEntry
+		for _, name := range preferCommands(names, pkg.Name) {
+			target := bin[name].(string)
+			entries = append(entries, nodeEntry{field: "bin." + name, label: "bin: " + name, path: target})
+		}
+		return entries
+	}
+	return nil
+}
+
+// exportsEntry resolves the main export ("." or the root conditions) to a path
+func exportsEntry(exports interface{}) string {
+	switch e := exports.(type) {
+	case string:
+		return e
+	case []interface{}:
+		for _, alt := range e {
+			if p := exportsEntry(alt); p != "" {
+				return p
+			}
+		}
+	case map[string]interface{}:
+		if root, ok := e["."]; ok {
+			return exportsEntry(root)
+		}
+		// Conditions applicable to running under node, in priority order
+		for _, cond := range []string{"node", "import", "require", "default"} {
+			if v, ok := e[cond]; ok {
+				if p := exportsEntry(v); p != "" {
+					return p
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// jsRuntime describes how a JavaScript runtime resolves and starts entry files
+type jsRuntime struct {
+	command    string
+	runArgs    []string // Placed before the entry file, e.g. "run" for bun
+	extensions []string // Tried when a declared path has no extension
+	fallbacks  []string // Conventional entry files when package.json declares none
+}
+
+var nodeRuntime = jsRuntime{
+	command:    "node",
+	extensions: []string{".js", ".mjs", ".cjs"},
+	fallbacks: []string{
+		"dist/index.js", "dist/index.mjs", "dist/index.cjs",
+		"build/index.js", "build/index.mjs", "build/index.cjs",
+		"index.js", "index.mjs", "index.cjs",
+	},
+}
+
+// resolveFile finds rel inside dir the way node resolves a main field,
+// trying the runtime's extensions and index files. Returns "" if nothing matches.
+func (rt jsRuntime) resolveFile(dir, rel string) string {
+	base := filepath.Join(dir, filepath.FromSlash(rel))
+	candidates := []string{base}
+	for _, ext := range rt.extensions {
+		candidates = append(candidates, base+ext)
+	}
+	for _, ext := range rt.extensions {
+		candidates = append(candidates, filepath.Join(base, "index"+ext))
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func (rt jsRuntime) candidate(label, file string) Candidate {
+	args := append(append([]string{}, rt.runArgs...), file)
+	return Candidate{Label: label, Command: rt.command, Args: args}
+}
+
+// resolveEntry returns the ways to start the (built) package in dir.
+// Entries declared in package.json win over conventional build outputs, and
+// must exist once the build has run.
+func (rt jsRuntime) resolveEntry(dir string, pkg PackageJSON) ([]Candidate, error) {
+	if entries := declaredNodeEntries(pkg); len(entries) > 0 {
+		var candidates []Candidate
+		for _, e := range entries {
+			if file := rt.resolveFile(dir, e.path); file != "" {
+				candidates = append(candidates, rt.candidate(e.label, file))
+			}
+		}
+		if len(candidates) == 0 {
+			e := entries[0]
+			return nil, fmt.Errorf("package.json %s points to %s, which does not exist in %s after build", e.field, e.path, dir)
+		}
+		return candidates, nil
+	}
+
+	for _, rel := range rt.fallbacks {
+		if file := rt.resolveFile(dir, rel); file != "" {
+			return []Candidate{rt.candidate("file: "+rel, file)}, nil
+		}
+	}
+	return nil, fmt.Errorf("could not find %s entry point in %s (no bin, exports or main in package.json, and no %s)", rt.command, dir, rt.fallbacks[0])
+}
+
+// resolveNodeEntry returns the ways to start the package in dir with node
+func resolveNodeEntry(dir string, pkg PackageJSON) ([]Candidate, error) {
+	return nodeRuntime.resolveEntry(dir, pkg)
+}
+
 // findMonorepoEntry searches for MCP server entry points in a monorepo
 func findMonorepoEntry(path string) string {
 	packagesDir := filepath.Join(path, "packages")
@@ -32,24 +205,10 @@ func findMonorepoEntry(path string) string {
 			continue
 		}
 
-		// Check for dist/index.js (TypeScript compiled output)
-		distEntry := filepath.Join(pkgPath, "dist", "index.js")
-		if exists(distEntry) {
-			return distEntry
-		}
-
-		// Check package.json for bin or main
-		pkgJSON := filepath.Join(pkgPath, "package.json")
-		if exists(pkgJSON) {
-			data, _ := os.ReadFile(pkgJSON)
-			var pkg PackageJSON
-			json.Unmarshal(data, &pkg)
-
-			if pkg.Main != "" {
-				mainPath := filepath.Join(pkgPath, pkg.Main)
-				if exists(mainPath) {
-					return mainPath
-				}
+		// Check package.json for bin, exports or main, then build outputs
+		if pkg, err := readPackageJSON(pkgPath); err == nil {
+			if candidates, err := resolveNodeEntry(pkgPath, pkg); err == nil {
+				return candidates[0].Args[0]
 			}
 		}
 
@@ -62,27 +221,21 @@ func findMonorepoEntry(path string) string {
 
 	// Scan all packages for MCP-related ones
 	entries, _ := os.ReadDir(packagesDir)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			continue
 		}
 		pkgPath := filepath.Join(packagesDir, entry.Name())
-		pkgJSON := filepath.Join(pkgPath, "package.json")
-
-		if exists(pkgJSON) {
-			data, _ := os.ReadFile(pkgJSON)
-			var pkg struct {
-				Name string `json:"name"`
-				Bin  interface{} `json:"bin"`
-			}
-			json.Unmarshal(data, &pkg)
+		pkg, err := readPackageJSON(pkgPath)
+		if err != nil {
+			continue
+		}
 
-			// Check if this looks like an MCP package
-			if strings.Contains(pkg.Name, "mcp") || pkg.Bin != nil {
-				distEntry := filepath.Join(pkgPath, "dist", "index.js")
-				if exists(distEntry) {
-					return distEntry
-				}
+		// Check if this looks like an MCP package
+		if strings.Contains(pkg.Name, "mcp") || pkg.Bin != nil {
+			if candidates, err := resolveNodeEntry(pkgPath, pkg); err == nil {
+				return candidates[0].Args[0]
 			}
 		}
 	}
@@ -90,7 +243,38 @@ func findMonorepoEntry(path string) string {
 	return ""
 }
 
-func buildNode(path string) (*BuildResult, error) {
+func buildNode(ctx context.Context, path string) (*BuildResult, error) {
+	pkg, err := installNodeDeps(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BuildResult{
+		EnvNeeds: []EnvVar{},
+	}
+
+	// Determine Entry - a root bin/exports wins, then a monorepo package
+	if len(binEntries(pkg)) == 0 && exportsEntry(pkg.Exports) == "" && exists(filepath.Join(path, "packages")) {
+		if absEntry := findMonorepoEntry(path); absEntry != "" {
+			result.Use(Candidate{Command: "node", Args: []string{absEntry}})
+			return result, nil
+		}
+	}
+
+	candidates, err := resolveNodeEntry(path, pkg)
+	if err != nil {
+		return nil, err
+	}
+	result.Use(candidates[0])
+	if len(candidates) > 1 {
+		result.Candidates = candidates
+	}
+	return result, nil
+}
+
+// installNodeDeps installs dependencies with the project's package manager
+// and runs its build script, if any
+func installNodeDeps(ctx context.Context, path string) (PackageJSON, error) {
 	mgr := "npm"
 	if exists(filepath.Join(path, "yarn.lock")) && commandExists("yarn") {
 		mgr = "yarn"
@@ -100,47 +284,20 @@ func buildNode(path string) (*BuildResult, error) {
 	}
 
 	// Install
-	if err := runShellCmd(path, mgr+" install"); err != nil {
-		return nil, err
+	if err := runShellCmd(ctx, path, mgr+" install"); err != nil {
+		return PackageJSON{}, err
 	}
 
 	// Build if script exists
-	pkgData, _ := os.ReadFile(filepath.Join(path, "package.json"))
-	var pkg PackageJSON
-	json.Unmarshal(pkgData, &pkg)
-
-	if _, hasBuild := pkg.Scripts["build"]; hasBuild {
-		if err := runShellCmd(path, mgr+" run build"); err != nil {
-			return nil, err
-		}
+	pkg, err := readPackageJSON(path)
+	if err != nil {
+		return PackageJSON{}, err
 	}
 
-	// Determine Entry - check for monorepo structure first
-	var absEntry string
-
-	// Check for monorepo with packages/ directory
-	if exists(filepath.Join(path, "packages")) {
-		absEntry = findMonorepoEntry(path)
-	}
-
-	// If not found in monorepo, use standard detection
-	if absEntry == "" {
-		entryFile := "index.js"
-		if pkg.Main != "" {
-			entryFile = pkg.Main
-		}
-		// Check dist/
-		if exists(filepath.Join(path, "dist", "index.js")) {
-			entryFile = filepath.Join("dist", "index.js")
-		} else if exists(filepath.Join(path, "build", "index.js")) {
-			entryFile = filepath.Join("build", "index.js")
+	if _, hasBuild := pkg.Scripts["build"]; hasBuild {
+		if err := runShellCmd(ctx, path, mgr+" run build"); err != nil {
+			return PackageJSON{}, err
 		}
-		absEntry = filepath.Join(path, entryFile)
 	}
-
-	return &BuildResult{
-		Command:  "node",
-		Args:     []string{absEntry},
-		EnvNeeds: []string{}, // Node specific ENV extraction is complex, skipping for MVP
-	}, nil
+	return pkg, nil
 }
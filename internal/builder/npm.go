@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// installNpm installs an npm package into dir, a private project whose only
+// dependency is the package at the requested version, and runs one of its
+// bin commands from node_modules/.bin
+func installNpm(ctx context.Context, dir, pkgName, version string) (*BuildResult, string, error) {
+	if !commandExists("npm") {
+		return nil, "", fmt.Errorf("npm is required to install %s", pkgName)
+	}
+	if version == "" {
+		version = "latest"
+	}
+	manifest, _ := json.MarshalIndent(map[string]interface{}{
+		"name":         "mcpm-" + filepath.Base(dir),
+		"private":      true,
+		"dependencies": map[string]string{pkgName: version},
+	}, "", "  ")
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), append(manifest, '\n'), 0644); err != nil {
+		return nil, "", err
+	}
+
+	// update moves within the range once installed; install resolves it first
+	step := "npm install --omit=dev --no-audit --no-fund"
+	if exists(filepath.Join(dir, "node_modules", pkgName)) {
+		step = "npm update --omit=dev --no-audit --no-fund"
+	}
+	if err := runShellCmd(ctx, dir, step); err != nil {
+		return nil, "", err
+	}
+
+	pkgDir := filepath.Join(dir, "node_modules", filepath.FromSlash(pkgName))
+	pkg, err := readPackageJSON(pkgDir)
+	if err != nil {
+		return nil, "", fmt.Errorf("npm did not install %s: %w", pkgName, err)
+	}
+
+	var candidates []Candidate
+	for _, e := range binEntries(pkg) {
+		// A bin string is named after the unscoped package
+		name := strings.TrimPrefix(e.field, "bin.")
+		if e.field == "bin" {
+			name = pkgName[strings.LastIndex(pkgName, "/")+1:]
+		}
+		bin := filepath.Join(dir, "node_modules", ".bin", name)
+		if runtime.GOOS == "windows" {
+			bin += ".cmd"
+		}
+		if exists(bin) {
+			candidates = append(candidates, Candidate{Label: e.label, Command: bin})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, "", fmt.Errorf("npm package %s@%s has no bin command to run", pkgName, pkg.Version)
+	}
+
+	result := &BuildResult{EnvNeeds: discoverPackageEnv(pkgDir)}
+	result.Use(candidates[0])
+	if len(candidates) > 1 {
+		result.Candidates = candidates
+	}
+	return result, pkg.Version, nil
+}
@@ -0,0 +1,36 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"mcpm/internal/config"
+)
+
+// InstallPackage installs a published package into dir and returns how to
+// start it with the version installed. Installing again moves to the
+// newest version matching the requested one (empty for latest).
+func InstallPackage(ctx context.Context, dir, kind, pkg, version string) (*BuildResult, string, error) {
+	ctx, cancel := config.WithBuildTimeout(ctx)
+	defer cancel()
+
+	var result *BuildResult
+	var resolved string
+	var err error
+	switch kind {
+	case "npm":
+		result, resolved, err = installNpm(ctx, dir, pkg, version)
+	case "pypi":
+		result, resolved, err = installPyPI(ctx, dir, pkg, version)
+	case "go":
+		result, resolved, err = installGoModule(ctx, dir, pkg, version)
+	default:
+		return nil, "", fmt.Errorf("unknown package kind %q", kind)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	result.Name = filepath.Base(dir)
+	return result, resolved, nil
+}
@@ -0,0 +1,86 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// nativeMagics are the leading bytes of ELF, Mach-O and PE executables
+var nativeMagics = [][]byte{
+	{0x7f, 'E', 'L', 'F'},
+	{0xfe, 0xed, 0xfa, 0xce}, {0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe}, {0xcf, 0xfa, 0xed, 0xfe},
+	{0xca, 0xfe, 0xba, 0xbe}, // Universal Mach-O
+	{'M', 'Z'},
+}
+
+// isNativeBinary reports whether the file is a compiled executable,
+// leaving out shared libraries
+func isNativeBinary(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	if strings.HasSuffix(name, ".so") || strings.Contains(name, ".so.") ||
+		strings.HasSuffix(name, ".dylib") || strings.HasSuffix(name, ".dll") {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, 4)
+	n, _ := f.Read(head)
+	for _, magic := range nativeMagics {
+		if n >= len(magic) && bytes.Equal(head[:len(magic)], magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// findPrebuiltBinaries returns the executables shipped in the root or bin/,
+// e.g. by a release archive
+func findPrebuiltBinaries(path string) []string {
+	var names []string
+	for _, dir := range []string{".", "bin"} {
+		entries, _ := os.ReadDir(filepath.Join(path, dir))
+		for _, entry := range entries {
+			rel := filepath.Join(dir, entry.Name())
+			if entry.Type().IsRegular() && isNativeBinary(filepath.Join(path, rel)) {
+				names = append(names, rel)
+			}
+		}
+	}
+	return names
+}
+
+// buildPrebuilt registers an executable that ships ready to run
+func buildPrebuilt(path string) (*BuildResult, error) {
+	bins := findPrebuiltBinaries(path)
+	if len(bins) == 0 {
+		return nil, fmt.Errorf("no prebuilt executable found in %s or %s", path, filepath.Join(path, "bin"))
+	}
+	sortByMCP(bins)
+
+	var candidates []Candidate
+	for _, rel := range bins {
+		bin := filepath.Join(path, rel)
+		if runtime.GOOS != "windows" {
+			// Zip archives don't always keep the executable bit
+			if err := os.Chmod(bin, 0755); err != nil {
+				return nil, err
+			}
+		}
+		candidates = append(candidates, Candidate{Label: "binary: " + filepath.ToSlash(rel), Command: bin})
+	}
+
+	result := &BuildResult{EnvNeeds: []EnvVar{}}
+	result.Use(candidates[0])
+	if len(candidates) > 1 {
+		result.Candidates = candidates
+	}
+	return result, nil
+}
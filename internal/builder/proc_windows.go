@@ -0,0 +1,16 @@
+//go:build windows
+
+package builder
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// setProcessGroup makes cancellation kill the whole process tree, since
+// Windows has no process groups that can be signalled as a unit.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+	}
+}
@@ -0,0 +1,79 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// pypiMetadataScript prints the installed version and console scripts of a
+// distribution
+const pypiMetadataScript = `import json, sys
+from importlib.metadata import distribution
+d = distribution(sys.argv[1])
+print(json.dumps({"version": d.version, "scripts": [e.name for e in d.entry_points if e.group == "console_scripts"]}))`
+
+// venvCommand is the path of a command installed into a venv
+func venvCommand(venvPath, name string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(venvPath, "Scripts", name+".exe")
+	}
+	return filepath.Join(venvPath, "bin", name)
+}
+
+// installPyPI installs a PyPI package into a venv in dir and runs one of its
+// console scripts. spec is a version specifier such as "==1.2.3" or ">=1",
+// empty for the latest release.
+func installPyPI(ctx context.Context, dir, pkgName, spec string) (*BuildResult, string, error) {
+	venvPath := filepath.Join(dir, ".venv")
+	pythonPath := venvCommand(venvPath, "python")
+
+	if !exists(pythonPath) {
+		if err := runShellCmd(ctx, dir, "python3 -m venv .venv"); err != nil {
+			if err := runShellCmd(ctx, dir, "python -m venv .venv"); err != nil {
+				return nil, "", fmt.Errorf("failed to create venv: %w", err)
+			}
+		}
+	}
+
+	// --upgrade re-resolves the specifier on update
+	requirement := "'" + pkgName + spec + "'"
+	if err := runShellCmd(ctx, dir, pythonPath+" -m pip install --upgrade "+requirement); err != nil {
+		return nil, "", err
+	}
+
+	// Extras don't belong to the distribution name, e.g. "pkg[cli]"
+	dist, _, _ := strings.Cut(pkgName, "[")
+	out, err := exec.CommandContext(ctx, pythonPath, "-c", pypiMetadataScript, dist).Output()
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read the metadata of %s: %w", dist, err)
+	}
+	var meta struct {
+		Version string   `json:"version"`
+		Scripts []string `json:"scripts"`
+	}
+	if err := json.Unmarshal(out, &meta); err != nil {
+		return nil, "", fmt.Errorf("could not read the metadata of %s: %w", dist, err)
+	}
+
+	var candidates []Candidate
+	for _, name := range preferCommands(meta.Scripts, dist) {
+		if script := venvCommand(venvPath, name); exists(script) {
+			candidates = append(candidates, Candidate{Label: "script: " + name, Command: script})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, "", fmt.Errorf("PyPI package %s %s has no console script to run", dist, meta.Version)
+	}
+
+	result := &BuildResult{EnvNeeds: []EnvVar{}}
+	result.Use(candidates[0])
+	if len(candidates) > 1 {
+		result.Candidates = candidates
+	}
+	return result, meta.Version, nil
+}
@@ -1,22 +1,53 @@
 package builder
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
 )
 
-func buildPython(path string) (*BuildResult, error) {
-	// Create venv
-	venvPath := filepath.Join(path, ".venv")
-	// Force python3
-	if err := runShellCmd(path, "python3 -m venv .venv"); err != nil {
-		// Fallback to just python
-		if err := runShellCmd(path, "python -m venv .venv"); err != nil {
-			return nil, fmt.Errorf("failed to create venv: %w", err)
+// pythonLockTools maps lockfiles to the tool that manages them, in order of preference
+var pythonLockTools = []struct {
+	lockfile string
+	tool     string
+}{
+	{"uv.lock", "uv"},
+	{"poetry.lock", "poetry"},
+	{"pdm.lock", "pdm"},
+}
+
+// detectPythonTool returns the project manager matching the repo's lockfile,
+// or "" when pip should be used (no lockfile, or the tool is not installed).
+func detectPythonTool(path string) string {
+	for _, t := range pythonLockTools {
+		if exists(filepath.Join(path, t.lockfile)) && commandExists(t.tool) {
+			return t.tool
 		}
 	}
+	return ""
+}
+
+// syncPythonLock installs the locked dependencies into <path>/.venv
+func syncPythonLock(ctx context.Context, path, tool string) error {
+	switch tool {
+	case "uv":
+		return runShellCmd(ctx, path, "uv sync --frozen --no-dev", "UV_PROJECT_ENVIRONMENT=.venv")
+	case "poetry":
+		return runShellCmd(ctx, path, "poetry install --no-interaction --only main",
+			"POETRY_VIRTUALENVS_CREATE=true", "POETRY_VIRTUALENVS_IN_PROJECT=true")
+	case "pdm":
+		return runShellCmd(ctx, path, "pdm install --prod", "PDM_VENV_IN_PROJECT=true")
+	}
+	return fmt.Errorf("unsupported python tool: %s", tool)
+}
 
+func buildPython(ctx context.Context, path string) (*BuildResult, error) {
+	venvPath := filepath.Join(path, ".venv")
 	pipPath := filepath.Join(venvPath, "bin", "pip")
 	pythonPath := filepath.Join(venvPath, "bin", "python")
 	if runtime.GOOS == "windows" {
@@ -24,36 +55,133 @@ func buildPython(path string) (*BuildResult, error) {
 		pythonPath = filepath.Join(venvPath, "Scripts", "python.exe")
 	}
 
-	// Install Deps
-	if exists(filepath.Join(path, "requirements.txt")) {
-		if err := runShellCmd(path, pipPath+" install -r requirements.txt"); err != nil {
+	if tool := detectPythonTool(path); tool != "" {
+		// Locked project: let its own tool create the venv with pinned deps
+		if err := syncPythonLock(ctx, path, tool); err != nil {
 			return nil, err
 		}
-	} else if exists(filepath.Join(path, "pyproject.toml")) {
-		if err := runShellCmd(path, pipPath+" install ."); err != nil {
-			return nil, err
+		if !exists(pythonPath) {
+			return nil, fmt.Errorf("%s did not create a virtual environment in %s", tool, venvPath)
+		}
+	} else {
+		// Create venv
+		// Force python3
+		if err := runShellCmd(ctx, path, "python3 -m venv .venv"); err != nil {
+			// Fallback to just python
+			if err := runShellCmd(ctx, path, "python -m venv .venv"); err != nil {
+				return nil, fmt.Errorf("failed to create venv: %w", err)
+			}
+		}
+
+		// Install Deps
+		if exists(filepath.Join(path, "requirements.txt")) {
+			if err := runShellCmd(ctx, path, pipPath+" install -r requirements.txt"); err != nil {
+				return nil, err
+			}
+		} else if exists(filepath.Join(path, "pyproject.toml")) {
+			if err := runShellCmd(ctx, path, pipPath+" install ."); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	// Find Entry Point
-	candidates := []string{"main.py", "server.py", "app.py", "src/main.py", "src/server.py"}
-	var entryPoint string
-	for _, c := range candidates {
-		if exists(filepath.Join(path, c)) {
-			entryPoint = c
-			break
+	candidates := findPythonEntries(path, venvPath, pythonPath)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("could not auto-detect python entry point (no [project.scripts], __main__.py, or main.py/server.py)")
+	}
+
+	result := &BuildResult{EnvNeeds: []EnvVar{}}
+	result.Use(candidates[0])
+	if len(candidates) > 1 {
+		result.Candidates = candidates
+	}
+	return result, nil
+}
+
+// pyProject holds the parts of pyproject.toml used to find entry points
+type pyProject struct {
+	Project struct {
+		Scripts map[string]string `toml:"scripts"`
+	} `toml:"project"`
+	Tool struct {
+		Poetry struct {
+			Scripts map[string]interface{} `toml:"scripts"` // string or {callable = "..."} table
+		} `toml:"poetry"`
+	} `toml:"tool"`
+}
+
+// findPythonEntries returns the ways the project can be started, most likely first:
+// console scripts installed into the venv, then packages with a __main__.py,
+// then well-known script files.
+func findPythonEntries(path, venvPath, pythonPath string) []Candidate {
+	var candidates []Candidate
+
+	// Console scripts declared in pyproject.toml
+	var names []string
+	if data, err := os.ReadFile(filepath.Join(path, "pyproject.toml")); err == nil {
+		var proj pyProject
+		if err := toml.Unmarshal(data, &proj); err == nil {
+			for name := range proj.Project.Scripts {
+				names = append(names, name)
+			}
+			for name := range proj.Tool.Poetry.Scripts {
+				if _, dup := proj.Project.Scripts[name]; !dup {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	sortByMCP(names)
+	for _, name := range names {
+		script := filepath.Join(venvPath, "bin", name)
+		if runtime.GOOS == "windows" {
+			script = filepath.Join(venvPath, "Scripts", name+".exe")
+		}
+		if exists(script) {
+			candidates = append(candidates, Candidate{
+				Label:   "script: " + name,
+				Command: script,
+				Args:    []string{},
+			})
+		}
+	}
+
+	// Packages runnable with python -m (src layout or flat layout)
+	var modules []string
+	for _, dir := range []string{filepath.Join(path, "src"), path} {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() || strings.HasPrefix(name, ".") || name == "tests" {
+				continue
+			}
+			if exists(filepath.Join(dir, name, "__main__.py")) {
+				modules = append(modules, name)
+			}
 		}
 	}
+	sortByMCP(modules)
+	for _, mod := range modules {
+		candidates = append(candidates, Candidate{
+			Label:   "module: python -m " + mod,
+			Command: pythonPath,
+			Args:    []string{"-m", mod},
+		})
+	}
 
-	if entryPoint == "" {
-		// Fallback: try to see if the package installed a CLI bin?
-		// For now, fail if no script found.
-		return nil, fmt.Errorf("could not auto-detect python entry point")
+	if len(candidates) > 0 {
+		return candidates
 	}
 
-	return &BuildResult{
-		Command:  pythonPath,
-		Args:     []string{filepath.Join(path, entryPoint)},
-		EnvNeeds: []string{},
-	}, nil
+	// Fall back to well-known script files
+	for _, c := range []string{"main.py", "server.py", "app.py", "src/main.py", "src/server.py"} {
+		if exists(filepath.Join(path, c)) {
+			return []Candidate{{
+				Label:   "file: " + c,
+				Command: pythonPath,
+				Args:    []string{filepath.Join(path, c)},
+			}}
+		}
+	}
+	return nil
 }
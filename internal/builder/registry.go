@@ -0,0 +1,210 @@
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ServerJSON is the official MCP registry's server.json
+type ServerJSON struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Version     string            `json:"version"`
+	Packages    []RegistryPackage `json:"packages"`
+}
+
+// RegistryPackage is one published distribution of the server
+type RegistryPackage struct {
+	RegistryType         string             `json:"registryType"` // "npm", "pypi", "oci", "nuget"
+	RegistryName         string             `json:"registryName"` // Older name of registryType
+	Identifier           string             `json:"identifier"`
+	Version              string             `json:"version"`
+	RuntimeHint          string             `json:"runtimeHint"` // e.g. "npx", "uvx", "docker"
+	Transport            *RegistryTransport `json:"transport"`
+	RuntimeArguments     []RegistryArgument `json:"runtimeArguments"`
+	PackageArguments     []RegistryArgument `json:"packageArguments"`
+	EnvironmentVariables []RegistryEnvVar   `json:"environmentVariables"`
+}
+
+type RegistryTransport struct {
+	Type string `json:"type"` // "stdio", "streamable-http", "sse"
+	URL  string `json:"url"`
+}
+
+type RegistryArgument struct {
+	Type    string `json:"type"` // "positional" or "named"
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Default string `json:"default"`
+}
+
+type RegistryEnvVar struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     string `json:"default"`
+	IsRequired  bool   `json:"isRequired"`
+	IsSecret    bool   `json:"isSecret"`
+}
+
+// registryRuntimes maps registry types to the runtime that runs their packages
+var registryRuntimes = map[string]string{
+	"npm":   "npx",
+	"pypi":  "uvx",
+	"oci":   "docker",
+	"nuget": "dnx",
+}
+
+func readServerJSON(path string) (*ServerJSON, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s ServerJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid server.json: %w", err)
+	}
+	return &s, nil
+}
+
+func (p RegistryPackage) registryType() string {
+	if p.RegistryType != "" {
+		return p.RegistryType
+	}
+	if p.RegistryName == "docker" {
+		return "oci"
+	}
+	return p.RegistryName
+}
+
+func (p RegistryPackage) runtime() string {
+	if p.RuntimeHint != "" {
+		return p.RuntimeHint
+	}
+	if p.registryType() == "oci" {
+		if rt, err := ContainerRuntime(); err == nil {
+			return rt
+		}
+	}
+	return registryRuntimes[p.registryType()]
+}
+
+// pickRegistryPackage prefers a package whose runtime is installed
+func pickRegistryPackage(packages []RegistryPackage) (RegistryPackage, error) {
+	var supported []RegistryPackage
+	for _, p := range packages {
+		if _, ok := registryRuntimes[p.registryType()]; ok {
+			supported = append(supported, p)
+		}
+	}
+	if len(supported) == 0 {
+		return RegistryPackage{}, fmt.Errorf("server.json has no npm, pypi, oci or nuget package")
+	}
+	for _, p := range supported {
+		if commandExists(p.runtime()) {
+			return p, nil
+		}
+	}
+	return supported[0], nil
+}
+
+// argumentValues flattens registry arguments into command line arguments
+func argumentValues(args []RegistryArgument) []string {
+	var out []string
+	for _, a := range args {
+		value := a.Value
+		if value == "" {
+			value = a.Default
+		}
+		switch a.Type {
+		case "named":
+			out = append(out, a.Name)
+			if value != "" {
+				out = append(out, value)
+			}
+		default:
+			if value != "" {
+				out = append(out, value)
+			}
+		}
+	}
+	return out
+}
+
+// buildFromServerJSON registers the published package declared in
+// server.json, run through its runtime (npx, uvx, docker...), instead of
+// building the repo
+func buildFromServerJSON(repoPath string, s *ServerJSON) (*BuildResult, error) {
+	pkg, err := pickRegistryPackage(s.Packages)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BuildResult{
+		Name:     filepath.Base(repoPath),
+		Command:  pkg.runtime(),
+		EnvNeeds: []EnvVar{},
+	}
+	for _, e := range pkg.EnvironmentVariables {
+		result.EnvNeeds = append(result.EnvNeeds, EnvVar{
+			Name:        e.Name,
+			Description: e.Description,
+			Default:     e.Default,
+			Secret:      e.IsSecret,
+			Optional:    !e.IsRequired,
+		})
+	}
+
+	runtimeArgs := argumentValues(pkg.RuntimeArguments)
+	packageArgs := argumentValues(pkg.PackageArguments)
+	var args []string
+	switch pkg.registryType() {
+	case "npm":
+		ref := pkg.Identifier
+		if pkg.Version != "" {
+			ref += "@" + pkg.Version
+		}
+		if result.Command == "npx" && !contains(runtimeArgs, "-y") && !contains(runtimeArgs, "--yes") {
+			args = append(args, "-y")
+		}
+		args = append(append(args, runtimeArgs...), ref)
+	case "pypi":
+		ref := pkg.Identifier
+		if pkg.Version != "" {
+			ref += "==" + pkg.Version
+		}
+		args = append(runtimeArgs, ref)
+	case "oci":
+		image := pkg.Identifier
+		if pkg.Version != "" && !strings.Contains(image[strings.LastIndex(image, "/")+1:], ":") {
+			image += ":" + pkg.Version
+		}
+		args = append([]string{"run", "-i", "--rm"}, runtimeArgs...)
+		args = append(args, image)
+		result.Image = image
+	case "nuget":
+		ref := pkg.Identifier
+		if pkg.Version != "" {
+			ref += "@" + pkg.Version
+		}
+		args = append(append([]string{ref}, runtimeArgs...), "--yes")
+	}
+	result.Args = append(args, packageArgs...)
+
+	if t := pkg.Transport; t != nil && (t.Type == "streamable-http" || t.Type == "sse") && t.URL != "" {
+		result.Transport = "http"
+		result.URL = t.URL
+	}
+	return result, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
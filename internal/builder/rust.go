@@ -0,0 +1,160 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// cargoManifest holds the parts of Cargo.toml used to find binaries
+type cargoManifest struct {
+	Package *struct {
+		Name       string `toml:"name"`
+		DefaultRun string `toml:"default-run"`
+		AutoBins   *bool  `toml:"autobins"`
+	} `toml:"package"`
+	Bin []struct {
+		Name string `toml:"name"`
+		Path string `toml:"path"`
+	} `toml:"bin"`
+	Workspace *struct {
+		Members []string `toml:"members"`
+	} `toml:"workspace"`
+}
+
+func readCargoManifest(dir string) (*cargoManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return nil, err
+	}
+	var m cargoManifest
+	if err := toml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid Cargo.toml in %s: %w", dir, err)
+	}
+	return &m, nil
+}
+
+// cargoBinaries lists the binary targets of the crate in dir, following
+// cargo's rules: [[bin]] entries, src/main.rs and src/bin/*.
+func cargoBinaries(dir string, m *cargoManifest) []string {
+	seen := make(map[string]bool)
+	var bins []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			bins = append(bins, name)
+		}
+	}
+
+	explicitMain := false
+	for _, b := range m.Bin {
+		add(b.Name)
+		if filepath.ToSlash(b.Path) == "src/main.rs" {
+			explicitMain = true
+		}
+	}
+
+	if m.Package == nil || (m.Package.AutoBins != nil && !*m.Package.AutoBins) {
+		return bins
+	}
+	if !explicitMain && exists(filepath.Join(dir, "src", "main.rs")) {
+		add(m.Package.Name)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "src", "bin"))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() && exists(filepath.Join(dir, "src", "bin", name, "main.rs")) {
+			add(name)
+		} else if !entry.IsDir() && strings.HasSuffix(name, ".rs") {
+			add(strings.TrimSuffix(name, ".rs"))
+		}
+	}
+	return bins
+}
+
+func buildRust(ctx context.Context, path string) (*BuildResult, error) {
+	root, err := readCargoManifest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect binaries from the root crate and every workspace member
+	var bins []string
+	defaultRun := ""
+	if root.Package != nil {
+		defaultRun = root.Package.DefaultRun
+		bins = cargoBinaries(path, root)
+	}
+	if root.Workspace != nil {
+		for _, pattern := range root.Workspace.Members {
+			dirs, _ := filepath.Glob(filepath.Join(path, filepath.FromSlash(pattern)))
+			for _, dir := range dirs {
+				if member, err := readCargoManifest(dir); err == nil {
+					bins = append(bins, cargoBinaries(dir, member)...)
+				}
+			}
+		}
+	}
+	if len(bins) == 0 {
+		return nil, fmt.Errorf("no binary targets found in Cargo.toml (library-only crate?)")
+	}
+
+	cmd := "cargo build --release --bins"
+	if root.Workspace != nil {
+		cmd += " --workspace"
+	}
+	if exists(filepath.Join(path, "Cargo.lock")) {
+		cmd += " --locked"
+	}
+	// Pin the target dir so a user-wide CARGO_TARGET_DIR doesn't move the binaries
+	targetDir := filepath.Join(path, "target")
+	if err := runShellCmd(ctx, path, cmd, "CARGO_TARGET_DIR="+targetDir); err != nil {
+		return nil, err
+	}
+
+	// default-run wins, then binaries mentioning "mcp", then the rest
+	if defaultRun != "" {
+		bins = []string{defaultRun}
+	} else {
+		var mcpBins []string
+		for _, bin := range bins {
+			if strings.Contains(strings.ToLower(bin), "mcp") {
+				mcpBins = append(mcpBins, bin)
+			}
+		}
+		if len(mcpBins) == 1 {
+			bins = mcpBins
+		}
+		sortByMCP(bins)
+	}
+
+	var candidates []Candidate
+	for _, bin := range bins {
+		binPath := filepath.Join(targetDir, "release", bin)
+		if runtime.GOOS == "windows" {
+			binPath += ".exe"
+		}
+		if exists(binPath) {
+			candidates = append(candidates, Candidate{
+				Label:   "bin: " + bin,
+				Command: binPath,
+				Args:    []string{},
+			})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("cargo build did not produce %s in %s", bins[0], filepath.Join(targetDir, "release"))
+	}
+
+	result := &BuildResult{EnvNeeds: []EnvVar{}}
+	result.Use(candidates[0])
+	if len(candidates) > 1 {
+		result.Candidates = candidates
+	}
+	return result, nil
+}
@@ -0,0 +1,204 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"mcpm/internal/config"
+)
+
+// buildManifestServers runs the manifest's install and build steps once
+// and returns the servers it lists. A server without runCmd gets its path
+// as BuildDir, built once the user has picked the servers.
+func buildManifestServers(ctx context.Context, repoPath string, m *Manifest) (*BuildResult, error) {
+	for _, step := range m.steps() {
+		if err := runShellCmd(ctx, repoPath, step); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &BuildResult{Name: filepath.Base(repoPath), EnvNeeds: []EnvVar{}}
+	for _, s := range m.Servers {
+		if s.Name == "" {
+			return nil, fmt.Errorf("mcp.json lists a server without a name")
+		}
+		dir := filepath.Join(repoPath, filepath.FromSlash(s.Path))
+		server := &BuildResult{Name: s.Name, EnvNeeds: []EnvVar{}}
+		for _, e := range append(m.envNeeds(), s.Env...) {
+			if !hasEnvVar(server.EnvNeeds, e.Name) {
+				server.EnvNeeds = append(server.EnvNeeds, e)
+			}
+		}
+		transport := &Manifest{Transport: s.Transport, Port: s.Port, URL: s.URL}
+		transport.applyTransport(server)
+
+		switch {
+		case s.RunCmd != "":
+			server.Command = resolveRepoPath(dir, s.RunCmd)
+			for _, arg := range s.Args {
+				server.Args = append(server.Args, resolveRepoPath(dir, arg))
+			}
+		case s.Path != "":
+			if detectProjectType(dir) == "" {
+				return nil, fmt.Errorf("server %s: could not detect the project type of %s", s.Name, s.Path)
+			}
+			server.BuildDir = dir
+		case !server.IsHTTP():
+			return nil, fmt.Errorf("server %s needs runCmd, path or url", s.Name)
+		}
+
+		if len(server.EnvNeeds) == 0 && s.Path != "" {
+			server.EnvNeeds = discoverEnv(dir, map[string]bool{})
+		}
+		result.Servers = append(result.Servers, server)
+	}
+	return result, nil
+}
+
+// mcpDependencyRe matches an MCP SDK among a project's dependencies
+var mcpDependencyRe = regexp.MustCompile(`(?im)modelcontextprotocol|fastmcp|mcp-go|\brmcp\b|(^|["'\s])mcp(\[[a-z,]*\])?\s*([<>=~!"']|$)`)
+
+// mcpDependencyFiles are where projects declare their dependencies
+var mcpDependencyFiles = []string{
+	"package.json", "pyproject.toml", "requirements.txt", "go.mod",
+	"Cargo.toml", "deno.json", "pom.xml", "build.gradle", "build.gradle.kts",
+}
+
+// serverPackages returns the directories under packages/ that look like
+// MCP servers: projects a builder recognises with "mcp" in their name or
+// an MCP SDK among their dependencies
+func serverPackages(repoPath string) []string {
+	entries, err := os.ReadDir(filepath.Join(repoPath, "packages"))
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(repoPath, "packages", entry.Name())
+		switch detectProjectType(dir) {
+		case "", "container", "prebuilt":
+			continue
+		}
+		if strings.Contains(strings.ToLower(entry.Name()), "mcp") {
+			dirs = append(dirs, dir)
+			continue
+		}
+		for _, name := range mcpDependencyFiles {
+			if data, err := os.ReadFile(filepath.Join(dir, name)); err == nil && mcpDependencyRe.Match(data) {
+				dirs = append(dirs, dir)
+				break
+			}
+		}
+	}
+	return dirs
+}
+
+// buildPackages returns a server per package of a monorepo. A JavaScript
+// workspace root is installed and built once for all of them; packages
+// whose entry point exists after that are ready, the others get their
+// directory as BuildDir.
+func buildPackages(ctx context.Context, repoPath string, dirs []string) (*BuildResult, error) {
+	workspace := false
+	if rootType := detectProjectType(repoPath); rootType == "node" || rootType == "bun" {
+		if _, err := prepareProject(ctx, repoPath, false); err != nil {
+			return nil, err
+		}
+		workspace = true
+	}
+
+	result := &BuildResult{Name: filepath.Base(repoPath), EnvNeeds: []EnvVar{}}
+	for _, dir := range dirs {
+		server := &BuildResult{Name: filepath.Base(dir), BuildDir: dir}
+		if projectType := detectProjectType(dir); workspace && (projectType == "node" || projectType == "bun") {
+			rt := nodeRuntime
+			if projectType == "bun" {
+				rt = bunRuntime
+			}
+			if pkg, err := readPackageJSON(dir); err == nil {
+				if candidates, err := rt.resolveEntry(dir, pkg); err == nil {
+					server.Use(candidates[0])
+					server.BuildDir = ""
+				}
+			}
+		}
+		server.EnvNeeds = discoverEnv(dir, map[string]bool{})
+		result.Servers = append(result.Servers, server)
+	}
+	return result, nil
+}
+
+// PrepareServers builds the projects the servers need, each once even when
+// several servers share it, and gives servers without a command the one
+// their project's builder found
+func PrepareServers(ctx context.Context, servers []*BuildResult) error {
+	ctx, cancel := config.WithBuildTimeout(ctx)
+	defer cancel()
+
+	built := make(map[string]*BuildResult)
+	for _, server := range servers {
+		if server.BuildDir == "" {
+			continue
+		}
+		// A project prepared for a server with its own command has no entry yet
+		b, done := built[server.BuildDir]
+		if !done || (b == nil && server.Command == "") {
+			var err error
+			if b, err = prepareProject(ctx, server.BuildDir, server.Command == ""); err != nil {
+				return fmt.Errorf("failed to build %s for %s: %w", server.BuildDir, server.Name, err)
+			}
+			built[server.BuildDir] = b
+		}
+		if server.Command == "" {
+			server.useBuild(b)
+		}
+	}
+	return nil
+}
+
+// prepareProject builds the project in dir. With withEntry it goes through
+// the project's builder and returns the entry point it found; otherwise
+// JavaScript projects only get their dependencies and build script.
+func prepareProject(ctx context.Context, dir string, withEntry bool) (*BuildResult, error) {
+	projectType := detectProjectType(dir)
+	if !withEntry {
+		switch projectType {
+		case "node":
+			_, err := installNodeDeps(ctx, dir)
+			return nil, err
+		case "bun":
+			_, err := installBunDeps(ctx, dir)
+			return nil, err
+		}
+	}
+	return buildType(ctx, dir, projectType, nil)
+}
+
+// useBuild takes the command, env and settings a builder found
+func (r *BuildResult) useBuild(b *BuildResult) {
+	r.Command, r.Args, r.Image = b.Command, b.Args, b.Image
+	for name, value := range b.Env {
+		if r.Env == nil {
+			r.Env = make(map[string]string)
+		}
+		if _, ok := r.Env[name]; !ok {
+			r.Env[name] = value
+		}
+	}
+	for _, e := range b.EnvNeeds {
+		if !hasEnvVar(r.EnvNeeds, e.Name) {
+			r.EnvNeeds = append(r.EnvNeeds, e)
+		}
+	}
+	for _, field := range b.Config {
+		if !hasEnvVar(r.Config, field.Name) {
+			r.Config = append(r.Config, field)
+		}
+	}
+}
@@ -1,16 +1,34 @@
 package builder
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"mcpm/internal/config"
 )
 
-func runShellCmd(dir string, command string) error {
+// runShellCmd runs command through the user's login shell in dir. Extra
+// environment variables can be passed as KEY=VALUE pairs.
+func runShellCmd(ctx context.Context, dir string, command string, env ...string) error {
 	if command == "" {
 		return nil
 	}
 
+	// Bound each step individually, on top of whatever deadline the caller set
+	stepTimeout := config.StepTimeout()
+	stepCtx := ctx
+	if stepTimeout > 0 {
+		var cancel context.CancelFunc
+		stepCtx, cancel = context.WithTimeout(ctx, stepTimeout)
+		defer cancel()
+	}
+
 	// Use user's shell with login/interactive flags to load profile (needed for nvm, etc.)
 	// Priority: zsh (macOS default) -> bash -> sh
 	shell := "sh"
@@ -24,19 +42,65 @@ func runShellCmd(dir string, command string) error {
 		args = []string{"-l", "-c", command}
 	}
 
-	cmd := exec.Command(shell, args...)
+	cmd := exec.CommandContext(stepCtx, shell, args...)
 	cmd.Dir = dir
-	cmd.Env = os.Environ() // Inherit current environment
+	cmd.Env = append(os.Environ(), env...) // Inherit current environment
+	// Kill the shell and everything it spawned when the context is done
+	setProcessGroup(cmd)
+	// Don't hang on grandchildren that keep the output pipe open
+	cmd.WaitDelay = 5 * time.Second
 
 	// Capture output for debugging
 	output, err := cmd.CombinedOutput()
 	if err != nil {
+		switch {
+		case ctx.Err() != nil:
+			return fmt.Errorf("%q aborted: %w", command, ctx.Err())
+		case errors.Is(stepCtx.Err(), context.DeadlineExceeded):
+			return fmt.Errorf("%q timed out after %s", command, stepTimeout)
+		}
 		return fmt.Errorf("%w: %s", err, string(output))
 	}
 	return nil
 }
 
+// sortByMCP sorts names alphabetically, with names mentioning "mcp" first
+func sortByMCP(names []string) {
+	sort.SliceStable(names, func(i, j int) bool {
+		mi := strings.Contains(strings.ToLower(names[i]), "mcp")
+		mj := strings.Contains(strings.ToLower(names[j]), "mcp")
+		if mi != mj {
+			return mi
+		}
+		return names[i] < names[j]
+	})
+}
+
 func exists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
 }
+
+// preferCommands narrows the commands a package installs to the one named
+// after the package, or else the ones mentioning "mcp". All are returned,
+// "mcp" ones first, when none match.
+func preferCommands(names []string, pkgName string) []string {
+	names = append([]string{}, names...)
+	sortByMCP(names)
+
+	// Unscoped package name, e.g. "@acme/weather-mcp" -> "weather-mcp"
+	pkgName = pkgName[strings.LastIndex(pkgName, "/")+1:]
+	var preferred []string
+	for _, name := range names {
+		if name == pkgName {
+			return []string{name}
+		}
+		if strings.Contains(strings.ToLower(name), "mcp") {
+			preferred = append(preferred, name)
+		}
+	}
+	if len(preferred) > 0 {
+		return preferred
+	}
+	return names
+}
@@ -0,0 +1,259 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"mcpm/internal/jsonschema"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SmitheryConfig is a Smithery smithery.yaml
+type SmitheryConfig struct {
+	StartCommand *SmitheryStartCommand `yaml:"startCommand"`
+}
+
+type SmitheryStartCommand struct {
+	Type            string                 `yaml:"type"` // "stdio" or "http"
+	ConfigSchema    map[string]interface{} `yaml:"configSchema"`
+	CommandFunction string                 `yaml:"commandFunction"` // JS: (config) => ({command, args, env})
+}
+
+// smitheryCommand is what commandFunction returns
+type smitheryCommand struct {
+	Command string                 `json:"command"`
+	Args    []interface{}          `json:"args"`
+	Env     map[string]interface{} `json:"env"`
+}
+
+func readSmithery(path string) (*SmitheryConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s SmitheryConfig
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid smithery.yaml: %w", err)
+	}
+	return &s, nil
+}
+
+// stdio returns the start command when it is a stdio command function
+func (s *SmitheryConfig) stdio() *SmitheryStartCommand {
+	if s.StartCommand == nil || s.StartCommand.CommandFunction == "" {
+		return nil
+	}
+	if s.StartCommand.Type != "" && s.StartCommand.Type != "stdio" {
+		return nil
+	}
+	return s.StartCommand
+}
+
+// configFields turns the config schema's properties into form fields,
+// required ones first
+func (c *SmitheryStartCommand) configFields() ([]EnvVar, error) {
+	if len(c.ConfigSchema) == 0 {
+		return nil, nil
+	}
+	schema, err := jsonschema.FromValue(c.ConfigSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smithery.yaml configSchema: %w", err)
+	}
+	schema = schema.Resolve()
+
+	required := make(map[string]bool)
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if required[names[i]] != required[names[j]] {
+			return required[names[i]]
+		}
+		return names[i] < names[j]
+	})
+
+	fields := make([]EnvVar, 0, len(names))
+	for _, name := range names {
+		prop := schema.Properties[name].Resolve()
+		field := EnvVar{
+			Name:        name,
+			Description: prop.Description,
+			Optional:    !required[name],
+			Secret:      looksSecret(name),
+		}
+		if field.Description == "" {
+			field.Description = prop.Title
+		}
+		if len(prop.Type) > 0 {
+			field.Type = prop.Type[0]
+		}
+		if len(prop.Enum) > 0 {
+			var values []string
+			for _, v := range prop.Enum {
+				values = append(values, fmt.Sprint(v))
+			}
+			field.Description = strings.TrimSpace(field.Description + " (one of " + strings.Join(values, ", ") + ")")
+		}
+		if field.Type == "array" {
+			field.Description = strings.TrimSpace(field.Description + " (comma-separated)")
+		}
+		if prop.Default != nil {
+			field.Default = configString(prop.Default)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// looksSecret guesses whether a setting holds a credential
+func looksSecret(name string) bool {
+	lower := strings.ToLower(name)
+	for _, word := range []string{"key", "token", "secret", "password"} {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// configString formats a config value the way it is entered in the form
+func configString(v interface{}) string {
+	if list, ok := v.([]interface{}); ok {
+		var items []string
+		for _, item := range list {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// command evaluates commandFunction with a config whose values are the
+// settings' placeholders, so the result can be filled in after the form.
+// Uses node when available, and a static read of the function otherwise.
+func (c *SmitheryStartCommand) command(ctx context.Context, fields []EnvVar) (*smitheryCommand, error) {
+	if !commandExists("node") {
+		return parseCommandFunction(c.CommandFunction)
+	}
+
+	placeholders := make(map[string]string, len(fields))
+	for _, field := range fields {
+		placeholders[field.Name] = ConfigPlaceholder(field.Name)
+	}
+	config, _ := json.Marshal(placeholders)
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	const script = `const fn = (0, eval)("(" + process.env.MCPM_COMMAND_FUNCTION + ")");
+process.stdout.write(JSON.stringify(fn(JSON.parse(process.env.MCPM_CONFIG))));`
+	cmd := exec.CommandContext(ctx, "node", "-e", script)
+	cmd.Env = append(os.Environ(), "MCPM_COMMAND_FUNCTION="+c.CommandFunction, "MCPM_CONFIG="+string(config))
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("smithery.yaml commandFunction failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("smithery.yaml commandFunction failed: %w", err)
+	}
+	var result smitheryCommand
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("smithery.yaml commandFunction returned %q: %w", out, err)
+	}
+	return &result, nil
+}
+
+var (
+	jsCommandRe = regexp.MustCompile("command\\s*:\\s*['\"`]([^'\"`]+)['\"`]")
+	jsArgsRe    = regexp.MustCompile(`args\s*:\s*\[([^\]]*)\]`)
+	jsEnvRe     = regexp.MustCompile(`env\s*:\s*\{([^}]*)\}`)
+	jsEnvPairRe = regexp.MustCompile("['\"]?(\\w+)['\"]?\\s*:\\s*(['\"`][^'\"`]*['\"`]|config\\.\\w+|config\\[['\"]\\w+['\"]\\])")
+	jsValueRe   = regexp.MustCompile("^(?:['\"`]([^'\"`]*)['\"`]|config\\.(\\w+)|config\\[['\"](\\w+)['\"]\\])")
+)
+
+// parseCommandFunction reads command, args and env out of the usual shape
+// of a command function without running it
+func parseCommandFunction(fn string) (*smitheryCommand, error) {
+	m := jsCommandRe.FindStringSubmatch(fn)
+	if m == nil {
+		return nil, fmt.Errorf("could not read the command from smithery.yaml commandFunction (install node to evaluate it)")
+	}
+	result := &smitheryCommand{Command: m[1], Env: make(map[string]interface{})}
+	if m := jsArgsRe.FindStringSubmatch(fn); m != nil {
+		for _, item := range strings.Split(m[1], ",") {
+			if value, ok := jsValue(strings.TrimSpace(item)); ok {
+				result.Args = append(result.Args, value)
+			}
+		}
+	}
+	if m := jsEnvRe.FindStringSubmatch(fn); m != nil {
+		for _, pair := range jsEnvPairRe.FindAllStringSubmatch(m[1], -1) {
+			if value, ok := jsValue(pair[2]); ok {
+				result.Env[pair[1]] = value
+			}
+		}
+	}
+	return result, nil
+}
+
+// jsValue evaluates a string literal or config reference
+func jsValue(expr string) (interface{}, bool) {
+	m := jsValueRe.FindStringSubmatch(expr)
+	switch {
+	case m == nil:
+		return nil, false
+	case m[2] != "":
+		return ConfigPlaceholder(m[2]), true
+	case m[3] != "":
+		return ConfigPlaceholder(m[3]), true
+	}
+	return m[1], true
+}
+
+// applySmithery replaces the built command with the one smithery.yaml starts,
+// and asks for its config. Container builds keep their image and only take
+// the env, since the command runs inside the image.
+func applySmithery(ctx context.Context, repoPath string, result *BuildResult, start *SmitheryStartCommand) error {
+	fields, err := start.configFields()
+	if err != nil {
+		return err
+	}
+	cmd, err := start.command(ctx, fields)
+	if err != nil {
+		return err
+	}
+
+	if result.Image == "" {
+		if cmd.Command == "" {
+			return fmt.Errorf("smithery.yaml commandFunction returned no command")
+		}
+		args := make([]string, 0, len(cmd.Args))
+		for _, arg := range cmd.Args {
+			args = append(args, resolveRepoPath(repoPath, configString(arg)))
+		}
+		result.Command = resolveRepoPath(repoPath, cmd.Command)
+		result.Args = args
+		result.Candidates = nil
+	}
+	if len(cmd.Env) > 0 && result.Env == nil {
+		result.Env = make(map[string]string)
+	}
+	for name, value := range cmd.Env {
+		if value != nil {
+			result.Env[name] = configString(value)
+		}
+	}
+	result.Config = fields
+	return nil
+}
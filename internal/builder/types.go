@@ -2,17 +2,82 @@ package builder
 
 // BuildResult contains everything needed to run the server
 type BuildResult struct {
+	Name        string   // Server name to register under (defaults to the repo folder)
 	Command     string   // The executable
 	Args        []string // Arguments
-	EnvNeeds    []string // Environment variables required
+	EnvNeeds    []EnvVar // Environment variables to ask the user for
 	BuildErrors []error
+
+	// Env holds fixed environment variables to register with the server
+	Env map[string]string
+	// Config lists settings to ask the user for that are not environment
+	// variables. Their values replace ${user_config.NAME} in Command, Args
+	// and Env (see ApplyConfig).
+	Config []EnvVar
+
+	// Transport is "stdio" (default) or "http". HTTP servers are registered
+	// by URL and must be started separately with Command/Args.
+	Transport string
+	URL       string
+
+	// Image is set when Command runs a container image; env vars are then
+	// forwarded with -e flags (see RunArgs)
+	Image string
+
+	// Servers lists the servers of a repo that ships several, e.g. a Gemini
+	// CLI extension or a monorepo. Each is configured and registered under
+	// its own Name, and Command and Args are unused.
+	Servers []*BuildResult
+
+	// BuildDir is a project that has to be built before the server can
+	// run, shared by the servers in it (see PrepareServers). When Command
+	// is empty, the project's builder also gives the command.
+	BuildDir string
+
+	// GeminiExtension is the path of a Gemini CLI extension, which Gemini
+	// CLI gets installed into its extensions directory instead of having
+	// Servers registered in settings.json
+	GeminiExtension string
+
+	// Candidates lists alternative entry points when the builder found more
+	// than one. Command/Args hold the first one until the user picks.
+	Candidates []Candidate
+}
+
+// EnvVar describes an environment variable the server reads, or a
+// config setting (see BuildResult.Config)
+type EnvVar struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Secret      bool   `json:"secret,omitempty"`   // Mask the value while typing
+	Optional    bool   `json:"optional,omitempty"` // May be left empty
+	Suggested   bool   `json:"-"`                  // Discovered in the repo rather than declared; empty skips it
+	Type        string `json:"-"`                  // Config value type: "string" (default), "number", "integer", "boolean", "array", "directory", "file"
+}
+
+// Options tweak how DetectAndBuild builds a repo
+type Options struct {
+	Builder string // Force a builder ("container", "node", ...) instead of detecting one
+}
+
+// Builders lists the builder names accepted by Options.Builder and mcp.json "type"
+var Builders = []string{"node", "bun", "deno", "python", "go", "rust", "jvm", "container", "prebuilt"}
+
+// Candidate is one possible way to start the server
+type Candidate struct {
+	Label   string // Shown to the user, e.g. "script: my-server"
+	Command string
+	Args    []string
+}
+
+// Use makes c the command that will be registered
+func (r *BuildResult) Use(c Candidate) {
+	r.Command = c.Command
+	r.Args = c.Args
 }
 
-// Manifest represents an optional mcp.json file in the repo
-type Manifest struct {
-	Type        string   `json:"type"`        // "node", "python", "go"
-	BuildCmd    string   `json:"buildCmd"`    // Optional custom build command
-	RunCmd      string   `json:"runCmd"`      // The command to start it
-	Args        []string `json:"args"`        // Default args
-	RequiredEnv []string `json:"requiredEnv"` // Variables to ask the user for
+// IsHTTP reports whether the server is reached over HTTP rather than stdio
+func (r *BuildResult) IsHTTP() bool {
+	return r.Transport == "http"
 }
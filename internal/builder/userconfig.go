@@ -0,0 +1,106 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ConfigPlaceholder is replaced by the value of config setting name
+func ConfigPlaceholder(name string) string {
+	return "${user_config." + name + "}"
+}
+
+// Check validates a value entered for the field
+func (e EnvVar) Check(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if e.Optional {
+			return nil
+		}
+		return fmt.Errorf("%s is required", e.Name)
+	}
+	switch e.Type {
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%s must be a number", e.Name)
+		}
+	case "integer":
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%s must be a whole number", e.Name)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false", e.Name)
+		}
+	case "directory":
+		if info, err := os.Stat(value); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s: no directory %s", e.Name, value)
+		}
+	case "file":
+		if info, err := os.Stat(value); err != nil || info.IsDir() {
+			return fmt.Errorf("%s: no file %s", e.Name, value)
+		}
+	}
+	return nil
+}
+
+// Fields returns everything to ask the user for: env vars, then config.
+// With several Servers, a name they share is asked for once.
+func (r *BuildResult) Fields() []EnvVar {
+	if len(r.Servers) == 0 {
+		return append(append([]EnvVar{}, r.EnvNeeds...), r.Config...)
+	}
+	var fields []EnvVar
+	seen := make(map[string]bool)
+	for _, server := range r.Servers {
+		for _, field := range server.Fields() {
+			if !seen[field.Name] {
+				seen[field.Name] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+// ApplyConfig substitutes the config values into Command, Args, Env and URL.
+// Args and env vars that only referenced an unset setting are dropped, and
+// an arg that is just an array setting becomes one arg per value.
+func (r *BuildResult) ApplyConfig(values map[string]string) {
+	replacements := make([]string, 0, 2*len(r.Config))
+	arrays := make(map[string]string)
+	for _, field := range r.Config {
+		replacements = append(replacements, ConfigPlaceholder(field.Name), values[field.Name])
+		if field.Type == "array" {
+			arrays[ConfigPlaceholder(field.Name)] = values[field.Name]
+		}
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	r.Command = replacer.Replace(r.Command)
+	r.URL = replacer.Replace(r.URL)
+	var args []string
+	for _, arg := range r.Args {
+		if list, ok := arrays[arg]; ok {
+			for _, item := range strings.Split(list, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					args = append(args, item)
+				}
+			}
+			continue
+		}
+		if value := replacer.Replace(arg); value != "" || arg == "" {
+			args = append(args, value)
+		}
+	}
+	r.Args = args
+	for key, value := range r.Env {
+		if value = replacer.Replace(value); value == "" {
+			delete(r.Env, key)
+		} else {
+			r.Env[key] = value
+		}
+	}
+}
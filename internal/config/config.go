@@ -0,0 +1,184 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Keys read from ~/.mcpm.yaml
+const (
+	KeyStepTimeout  = "timeouts.step"  // Max duration of a single build step (e.g. "npm install")
+	KeyBuildTimeout = "timeouts.build" // Max duration of a fetch, and of a build
+
+	KeyGoCGOEnabled = "go.cgoEnabled" // Sets CGO_ENABLED for go builds when present
+	KeyGoTrimpath   = "go.trimpath"   // Pass -trimpath to go build
+	KeyGoTags       = "go.tags"       // Build tags passed with -tags
+
+	KeyGitHubAPI = "releases.githubAPI" // GitHub REST API base, e.g. for GitHub Enterprise
+
+	KeySchemes = "schemes" // Source shorthands, keyed by prefix
+)
+
+// Scheme is a source shorthand: <prefix>:@<path> clones URL with {path}
+// replaced. A prefix ending in ":{host}", like gitea:{host}, matches any
+// host, which replaces {host} in URL.
+type Scheme struct {
+	Prefix      string `mapstructure:"-"`
+	Name        string // Shown by "mcpm scheme list"
+	URL         string // e.g. "https://gitlab.example.com/{path}.git"
+	Layout      string // Repo paths: "groups" (default, GitLab's nested groups), "owner/repo" or "sourcehut" (~user/repo)
+	Protocol    string // "https" (default) or "ssh"
+	Credentials string // Token for https clones: "env:NAME" or "file:PATH"
+	Username    string // Sent with the token, "oauth2" by default
+	Forge       string // Releases API for install --release: "github" or "gitlab"
+	API         string // Forge API base, by default the host's /api/v3 (GitHub) or /api/v4 (GitLab)
+	Builtin     bool   `mapstructure:"-"`
+}
+
+// builtinSchemes are available without any config
+var builtinSchemes = []Scheme{
+	{Prefix: "gh", Name: "GitHub", URL: "https://github.com/{path}.git", Layout: "owner/repo", Forge: "github"},
+	{Prefix: "gl", Name: "GitLab", URL: "https://gitlab.com/{path}.git", Forge: "gitlab"},
+	{Prefix: "gl:rh", Name: "GitLab Red Hat", URL: "https://gitlab.cee.redhat.com/{path}.git", Forge: "gitlab"},
+	{Prefix: "bb", Name: "Bitbucket", URL: "https://bitbucket.org/{path}.git", Layout: "owner/repo"},
+	{Prefix: "cb", Name: "Codeberg", URL: "https://codeberg.org/{path}.git", Layout: "owner/repo"},
+	{Prefix: "srht", Name: "sourcehut", URL: "https://git.sr.ht/{path}", Layout: "sourcehut"},
+	{Prefix: "gitea:{host}", Name: "Gitea", URL: "https://{host}/{path}.git", Layout: "owner/repo"},
+	{Prefix: "forgejo:{host}", Name: "Forgejo", URL: "https://{host}/{path}.git", Layout: "owner/repo"},
+}
+
+// SetDefaults registers default values for every known key.
+// Must be called before the config file is read.
+func SetDefaults() {
+	viper.SetDefault(KeyStepTimeout, "10m")
+	viper.SetDefault(KeyBuildTimeout, "30m")
+	viper.SetDefault(KeyGoTrimpath, true)
+	viper.SetDefault(KeyGitHubAPI, "https://api.github.com")
+}
+
+// StepTimeout returns the per-step timeout. Zero means no limit.
+func StepTimeout() time.Duration {
+	return viper.GetDuration(KeyStepTimeout)
+}
+
+// BuildTimeout returns the timeout of a fetch or a build. Zero means no limit.
+func BuildTimeout() time.Duration {
+	return viper.GetDuration(KeyBuildTimeout)
+}
+
+// WithBuildTimeout bounds ctx by BuildTimeout, when there is one
+func WithBuildTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := BuildTimeout(); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// GoCGOEnabled returns the CGO_ENABLED value to build with, or "" to
+// inherit the environment.
+func GoCGOEnabled() string {
+	if !viper.IsSet(KeyGoCGOEnabled) {
+		return ""
+	}
+	if viper.GetBool(KeyGoCGOEnabled) {
+		return "1"
+	}
+	return "0"
+}
+
+// GoTrimpath reports whether go builds use -trimpath
+func GoTrimpath() bool {
+	return viper.GetBool(KeyGoTrimpath)
+}
+
+// GoTags returns the build tags for go builds
+func GoTags() []string {
+	return viper.GetStringSlice(KeyGoTags)
+}
+
+// GitHubAPI returns the base URL of the GitHub REST API
+func GitHubAPI() string {
+	return strings.TrimRight(viper.GetString(KeyGitHubAPI), "/")
+}
+
+// Schemes returns the built-in schemes followed by the configured ones.
+// A configured scheme with a built-in prefix changes the fields it sets,
+// e.g. to add credentials.
+func Schemes() ([]Scheme, error) {
+	var configured map[string]Scheme
+	if err := viper.UnmarshalKey(KeySchemes, &configured); err != nil {
+		return nil, fmt.Errorf("invalid %s in config: %w", KeySchemes, err)
+	}
+
+	schemes := make([]Scheme, len(builtinSchemes))
+	copy(schemes, builtinSchemes)
+	for i := range schemes {
+		schemes[i].Builtin = true
+		if c, ok := configured[schemes[i].Prefix]; ok {
+			schemes[i].merge(c)
+			delete(configured, schemes[i].Prefix)
+		}
+	}
+
+	prefixes := make([]string, 0, len(configured))
+	for prefix := range configured {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		s := configured[prefix]
+		s.Prefix = prefix
+		if s.Name == "" {
+			s.Name = prefix
+		}
+		schemes = append(schemes, s)
+	}
+
+	for _, s := range schemes {
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("scheme %s: %w", s.Prefix, err)
+		}
+	}
+	return schemes, nil
+}
+
+// merge overrides the fields c sets
+func (s *Scheme) merge(c Scheme) {
+	for _, f := range []struct{ dst, src *string }{
+		{&s.Name, &c.Name}, {&s.URL, &c.URL}, {&s.Layout, &c.Layout}, {&s.Protocol, &c.Protocol},
+		{&s.Credentials, &c.Credentials}, {&s.Username, &c.Username}, {&s.Forge, &c.Forge}, {&s.API, &c.API},
+	} {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+}
+
+func (s Scheme) validate() error {
+	switch {
+	case !strings.Contains(s.URL, "{path}"):
+		return fmt.Errorf("url must contain {path}")
+	case strings.HasSuffix(s.Prefix, ":{host}") && !strings.Contains(s.URL, "{host}"):
+		return fmt.Errorf("url must contain {host}")
+	case !strings.HasPrefix(s.URL, "https://") && !strings.HasPrefix(s.URL, "http://"):
+		return fmt.Errorf("url must be an http(s) URL, use protocol: ssh to clone over SSH")
+	case s.Credentials != "" && !strings.HasPrefix(s.URL, "https://"):
+		return fmt.Errorf("credentials are only sent over https, the url must be https://")
+	case s.Layout != "" && s.Layout != "groups" && s.Layout != "owner/repo" && s.Layout != "sourcehut":
+		return fmt.Errorf("layout must be groups, owner/repo or sourcehut, not %q", s.Layout)
+	case s.Protocol != "" && s.Protocol != "https" && s.Protocol != "ssh":
+		return fmt.Errorf("protocol must be https or ssh, not %q", s.Protocol)
+	case s.Credentials != "" && !strings.HasPrefix(s.Credentials, "env:") && !strings.HasPrefix(s.Credentials, "file:"):
+		return fmt.Errorf("credentials must be env:NAME or file:PATH")
+	case s.Forge != "" && s.Forge != "github" && s.Forge != "gitlab":
+		return fmt.Errorf("forge must be github or gitlab, not %q", s.Forge)
+	case s.API != "" && !strings.HasPrefix(s.API, "https://") && (s.Credentials != "" || !strings.HasPrefix(s.API, "http://")):
+		return fmt.Errorf("api must be an https:// URL")
+	}
+	return nil
+}
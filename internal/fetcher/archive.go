@@ -0,0 +1,522 @@
+package fetcher
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"mcpm/internal/config"
+)
+
+// archiveExtensions are the archive formats that can be installed. MCP
+// Bundles (.mcpb, formerly .dxt) are zip archives.
+var archiveExtensions = []string{".tar.gz", ".tgz", ".zip", ".mcpb", ".dxt"}
+
+// archiveVersionRe finds where a version starts in an archive name, e.g.
+// "weather-mcp-1.2.0-linux-amd64"
+var archiveVersionRe = regexp.MustCompile(`[-_]v?[0-9]+\.[0-9]+`)
+
+// archiveExt returns the archive extension of name, or ""
+func archiveExt(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// ParseArchive recognises a release archive: an http(s) URL or local path
+// ending in .tar.gz, .tgz, .zip, .mcpb or .dxt. ok is false for anything else.
+func ParseArchive(input string) (src Source, ok bool, err error) {
+	if strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
+		u, err := url.Parse(input)
+		if err != nil || archiveExt(u.Path) == "" {
+			return Source{}, false, nil
+		}
+		return Source{Kind: "archive", URL: input}, true, nil
+	}
+	if archiveExt(input) == "" {
+		return Source{}, false, nil
+	}
+	p := strings.TrimPrefix(input, "file://")
+	if strings.HasPrefix(p, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Source{}, true, err
+		}
+		p = filepath.Join(home, p[2:])
+	}
+	abs, err := filepath.Abs(filepath.FromSlash(p))
+	if err != nil {
+		return Source{}, true, err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return Source{}, true, fmt.Errorf("archive %s: %w", input, err)
+	}
+	return Source{Kind: "archive", Path: abs}, true, nil
+}
+
+// archiveName is the server name for an archive: its file name without the
+// extension, version and platform. Forge source archives, named after the
+// tag or branch, use the repo name instead.
+func archiveName(ref string) string {
+	isURL := false
+	if u, err := url.Parse(ref); err == nil && u.Scheme != "" {
+		ref, isURL = u.Path, true
+	}
+	base := path.Base(filepath.ToSlash(ref))
+	base = base[:len(base)-len(archiveExt(base))]
+	if loc := archiveVersionRe.FindStringIndex(base); loc != nil && loc[0] > 0 {
+		return base[:loc[0]]
+	}
+	if isURL {
+		if repo := forgeArchiveRepo(ref); repo != "" {
+			return repo
+		}
+	}
+	return base
+}
+
+// forgeArchiveRepo returns the repo in a forge's source archive path, e.g.
+// weather in /owner/weather/archive/refs/tags/v1.2.0.tar.gz (GitHub),
+// /group/weather/-/archive/v1.2.0/weather-v1.2.0.tar.gz (GitLab),
+// /owner/weather/archive/v1.2.0.tar.gz (Gitea, Codeberg, sourcehut) or
+// /owner/weather/get/v1.2.0.tar.gz (Bitbucket)
+func forgeArchiveRepo(urlPath string) string {
+	segs := strings.Split(strings.Trim(urlPath, "/"), "/")
+	for i := 2; i < len(segs)-1; i++ {
+		if segs[i] != "archive" && segs[i] != "get" {
+			continue
+		}
+		repo := segs[i-1]
+		if repo == "-" && i > 2 && segs[i] == "archive" {
+			repo = segs[i-2]
+		}
+		return repo
+	}
+	return ""
+}
+
+// FetchArchive downloads (or opens) an archive, checks it against
+// src.SHA256 when set, and extracts it into .mcp/servers/<name>, replacing
+// any previous version
+func FetchArchive(ctx context.Context, src Source) (string, error) {
+	ctx, cancel := config.WithBuildTimeout(ctx)
+	defer cancel()
+
+	name, err := src.Name()
+	if err != nil {
+		return "", err
+	}
+	target, err := serverDir(name)
+	if err != nil {
+		return "", err
+	}
+
+	var file *os.File
+	ref := src.URL
+	if ref != "" {
+		file, err = download(ctx, src.URL, nil)
+	} else {
+		ref = src.Path
+		file, err = copyToTemp(src.Path)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if src.SHA256 != "" {
+		if err := verifySHA256(file, src.SHA256); err != nil {
+			return "", err
+		}
+	}
+	if err := installArchive(file, ref, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// installArchive extracts an archive named name into target, replacing
+// what was there. A single top-level directory is stripped.
+func installArchive(file *os.File, name, target string) error {
+	// Extract next to the target so a failure leaves the old version alone
+	tmp, err := os.MkdirTemp(filepath.Dir(target), "."+filepath.Base(target)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	switch archiveExt(name) {
+	case ".tar.gz", ".tgz":
+		err = extractTarGz(file, tmp)
+	default:
+		err = extractZip(file, tmp)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+
+	root := tmp
+	if entries, err := os.ReadDir(tmp); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		root = filepath.Join(tmp, entries[0].Name())
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+	if err := os.Rename(root, target); err != nil {
+		return err
+	}
+	// MkdirTemp creates the directory private
+	return os.Chmod(target, 0755)
+}
+
+// download fetches url into a temporary file, positioned at the start
+func download(ctx context.Context, url string, header http.Header) (*os.File, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: %s returned %s", url, resp.Status)
+	}
+	return writeTemp(resp.Body)
+}
+
+// copyToTemp copies a local file into a temporary file, positioned at the start
+func copyToTemp(path string) (*os.File, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return writeTemp(in)
+}
+
+func writeTemp(r io.Reader) (*os.File, error) {
+	file, err := os.CreateTemp("", "mcpm-download-")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(file, r); err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	return file, nil
+}
+
+// verifySHA256 checks the file's checksum and rewinds it
+func verifySHA256(file *os.File, want string) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	got := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(got, strings.TrimSpace(want)) {
+		return fmt.Errorf("checksum mismatch: expected sha256 %s, got %s", want, got)
+	}
+	return nil
+}
+
+// safeJoin returns where an archive entry goes inside root, rejecting
+// absolute paths and paths that climb out of root
+func safeJoin(root, name string) (string, error) {
+	clean := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	local := filepath.FromSlash(clean)
+	if path.IsAbs(clean) || filepath.IsAbs(local) || filepath.VolumeName(local) != "" ||
+		clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("entry %q is outside the archive", name)
+	}
+	return filepath.Join(root, local), nil
+}
+
+// checkLink rejects a symlink at dest whose target resolves outside root.
+// The target is resolved the way the OS will, through the links extracted
+// so far, which can't be replaced later (see clearDest).
+func checkLink(root, dest, target string) error {
+	if filepath.IsAbs(target) || path.IsAbs(filepath.ToSlash(target)) || filepath.VolumeName(target) != "" {
+		return fmt.Errorf("symlink %s points to absolute path %s", dest, target)
+	}
+	if _, _, err := resolveLink(root, filepath.Dir(dest), target, 0); err != nil {
+		return fmt.Errorf("symlink %s points outside the archive (%s): %w", dest, target, err)
+	}
+	return nil
+}
+
+// resolveLink resolves target from dir, both inside root, following
+// symlinks. Once it reaches a path that isn't extracted yet, ".." is
+// refused: a later entry could make that path a link. missing reports
+// whether the result doesn't exist yet.
+func resolveLink(root, dir, target string, depth int) (resolved string, missing bool, err error) {
+	if depth > 40 {
+		return "", false, fmt.Errorf("too many levels of symlinks")
+	}
+	cur := dir
+	for _, part := range strings.FieldsFunc(target, func(r rune) bool { return r == '/' || r == '\\' }) {
+		switch {
+		case part == ".":
+		case part == "..":
+			if missing {
+				return "", false, fmt.Errorf(`".." after %s, which isn't extracted yet`, cur)
+			}
+			if cur == root {
+				return "", false, fmt.Errorf("climbs out of the archive")
+			}
+			cur = filepath.Dir(cur)
+		case missing:
+			cur = filepath.Join(cur, part)
+		default:
+			next := filepath.Join(cur, part)
+			info, err := os.Lstat(next)
+			switch {
+			case os.IsNotExist(err):
+				cur, missing = next, true
+			case err != nil:
+				return "", false, err
+			case info.Mode()&os.ModeSymlink != 0:
+				link, err := os.Readlink(next)
+				if err != nil {
+					return "", false, err
+				}
+				if filepath.IsAbs(link) {
+					return "", false, fmt.Errorf("%s points to absolute path %s", next, link)
+				}
+				if cur, missing, err = resolveLink(root, cur, link, depth+1); err != nil {
+					return "", false, err
+				}
+			default:
+				cur = next
+			}
+		}
+	}
+	return cur, missing, nil
+}
+
+// checkParents rejects writing through a symlink that was extracted earlier,
+// which could otherwise redirect later entries
+func checkParents(root, dest string) error {
+	rel, _ := filepath.Rel(root, filepath.Dir(dest))
+	if rel == "." {
+		return nil
+	}
+	dir := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		dir = filepath.Join(dir, part)
+		if info, err := os.Lstat(dir); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("entry %s is written through symlink %s", dest, dir)
+		}
+	}
+	return nil
+}
+
+// clearDest makes way for an entry at dest. A later file replaces an
+// earlier one, but nothing replaces a symlink, which opening would write
+// through, and nothing becomes a symlink once extracted, so checkLink's
+// view of the tree stays true.
+func clearDest(dest string, symlink bool) error {
+	info, err := os.Lstat(dest)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	case info.Mode()&os.ModeSymlink != 0:
+		return fmt.Errorf("entry %s would be written through a symlink", dest)
+	case info.IsDir():
+		return fmt.Errorf("entry %s is already a directory", dest)
+	case symlink:
+		return fmt.Errorf("symlink %s would replace a file", dest)
+	}
+	return os.Remove(dest)
+}
+
+func writeEntry(dest string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := clearDest(dest, false); err != nil {
+		return err
+	}
+	perm := mode.Perm()
+	if perm == 0 {
+		// Zip archives made on Windows have no permissions
+		perm = 0644
+	}
+	// O_EXCL fails on anything created at dest since, symlinks included
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// mkdirEntry creates a directory entry, which may not be an extracted symlink
+func mkdirEntry(dest string) error {
+	if info, err := os.Lstat(dest); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("directory %s would be created through a symlink", dest)
+	}
+	return os.MkdirAll(dest, 0755)
+}
+
+func writeSymlink(root, dest, target string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := checkLink(root, dest, target); err != nil {
+		return err
+	}
+	if err := clearDest(dest, true); err != nil {
+		return err
+	}
+	return os.Symlink(target, dest)
+}
+
+func extractTarGz(r io.Reader, root string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		dest, err := safeJoin(root, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if err := checkParents(root, dest); err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := mkdirEntry(dest); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeEntry(dest, tr, os.FileMode(hdr.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := writeSymlink(root, dest, hdr.Linkname); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			// Hard links name another entry of the archive
+			target, err := safeJoin(root, hdr.Linkname)
+			if err != nil {
+				return err
+			}
+			if err := checkParents(root, target); err != nil {
+				return err
+			}
+			if info, err := os.Lstat(target); err != nil || !info.Mode().IsRegular() {
+				return fmt.Errorf("hard link %s must name a file extracted earlier, not %s", dest, hdr.Linkname)
+			}
+			if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+				return err
+			}
+			if err := clearDest(dest, false); err != nil {
+				return err
+			}
+			if err := os.Link(target, dest); err != nil {
+				return err
+			}
+		default:
+			// Devices, fifos and the like have no place in a server release
+		}
+	}
+}
+
+func extractZip(file *os.File, root string) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		dest, err := safeJoin(root, f.Name)
+		if err != nil {
+			return err
+		}
+		if err := checkParents(root, dest); err != nil {
+			return err
+		}
+
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := mkdirEntry(dest); err != nil {
+				return err
+			}
+		case mode&os.ModeSymlink != 0:
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			target, err := io.ReadAll(io.LimitReader(rc, 4096))
+			rc.Close()
+			if err != nil {
+				return err
+			}
+			if err := writeSymlink(root, dest, string(target)); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			err = writeEntry(dest, rc, mode)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package fetcher
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// entry is one member of a test archive. Symlinks and hard links set link.
+type entry struct {
+	name string
+	body string
+	link string
+	typ  byte // tar type flag, tar.TypeReg when zero
+}
+
+func writeTarGz(t *testing.T, entries []entry) *os.File {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Typeflag: e.typ, Linkname: e.link}
+		switch e.typ {
+		case 0:
+			hdr.Typeflag = tar.TypeReg
+			hdr.Size = int64(len(e.body))
+		case tar.TypeDir:
+			hdr.Mode = 0755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return writeFixture(t, buf.Bytes())
+}
+
+func writeZip(t *testing.T, entries []entry) *os.File {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		fh := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		if e.typ == tar.TypeSymlink {
+			fh.SetMode(os.ModeSymlink | 0777)
+			body = e.link
+		} else {
+			fh.SetMode(0644)
+		}
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return writeFixture(t, buf.Bytes())
+}
+
+func writeFixture(t *testing.T, data []byte) *os.File {
+	t.Helper()
+	file, err := writeTemp(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		file.Close()
+		os.Remove(file.Name())
+	})
+	return file
+}
+
+// extractRoot returns an empty root inside a parent directory, where an
+// escaping entry would land
+func extractRoot(t *testing.T) (parent, root string) {
+	parent = t.TempDir()
+	root = filepath.Join(parent, "root")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return parent, root
+}
+
+var maliciousArchives = []struct {
+	name    string
+	entries []entry
+}{
+	{"parent path", []entry{{name: "../victim", body: "x"}}},
+	{"absolute path", []entry{{name: "/tmp/victim", body: "x"}}},
+	{"absolute symlink", []entry{
+		{name: "victim", link: "/tmp", typ: tar.TypeSymlink},
+	}},
+	{"symlink climbing out", []entry{
+		{name: "victim", link: "../..", typ: tar.TypeSymlink},
+	}},
+	{"symlink chain through extracted links", []entry{
+		{name: "y", link: ".", typ: tar.TypeSymlink},
+		{name: "x", link: "y/..", typ: tar.TypeSymlink},
+		{name: "z", link: "x/victim", typ: tar.TypeSymlink},
+		{name: "z", body: "x"},
+	}},
+	{"dotdot after a path extracted later", []entry{
+		{name: "x", link: "later/..", typ: tar.TypeSymlink},
+		{name: "later", link: ".", typ: tar.TypeSymlink},
+		{name: "x/victim", body: "x"},
+	}},
+	{"file written through a symlink", []entry{
+		{name: "sub/", typ: tar.TypeDir},
+		{name: "link", link: "sub", typ: tar.TypeSymlink},
+		{name: "link/victim", body: "x"},
+	}},
+	{"file replacing a symlink", []entry{
+		{name: "link", link: "victim", typ: tar.TypeSymlink},
+		{name: "link", body: "x"},
+	}},
+	{"symlink replacing a file", []entry{
+		{name: "file", body: "x"},
+		{name: "file", link: "sub", typ: tar.TypeSymlink},
+	}},
+	{"hard link through a symlink", []entry{
+		{name: "sub/file", body: "x"},
+		{name: "link", link: "sub", typ: tar.TypeSymlink},
+		{name: "victim", link: "link/file", typ: tar.TypeLink},
+	}},
+	{"hard link outside", []entry{
+		{name: "victim", link: "../outside", typ: tar.TypeLink},
+	}},
+}
+
+func TestExtractTarGzRejectsEscapes(t *testing.T) {
+	for _, tc := range maliciousArchives {
+		t.Run(tc.name, func(t *testing.T) {
+			parent, root := extractRoot(t)
+			if err := extractTarGz(writeTarGz(t, tc.entries), root); err == nil {
+				t.Fatal("extraction succeeded, want an error")
+			}
+			if _, err := os.Lstat(filepath.Join(parent, "victim")); err == nil {
+				t.Fatal("an entry was written outside the root")
+			}
+		})
+	}
+}
+
+func TestExtractZipRejectsEscapes(t *testing.T) {
+	for _, tc := range maliciousArchives {
+		if tc.entries[len(tc.entries)-1].typ == tar.TypeLink {
+			continue // Zip has no hard links
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			parent, root := extractRoot(t)
+			if err := extractZip(writeZip(t, tc.entries), root); err == nil {
+				t.Fatal("extraction succeeded, want an error")
+			}
+			if _, err := os.Lstat(filepath.Join(parent, "victim")); err == nil {
+				t.Fatal("an entry was written outside the root")
+			}
+		})
+	}
+}
+
+func TestExtractTarGzKeepsLinksInside(t *testing.T) {
+	_, root := extractRoot(t)
+	entries := []entry{
+		{name: "pkg/lib/server.js", body: "console.log(1)"},
+		{name: "pkg/bin/server", link: "../lib/server.js", typ: tar.TypeSymlink},
+		{name: "pkg/lib/current", link: ".", typ: tar.TypeSymlink},
+		{name: "pkg/bin/copy", link: "pkg/lib/server.js", typ: tar.TypeLink},
+		{name: "pkg/lib/server.js", body: "console.log(2)"},
+	}
+	if err := extractTarGz(writeTarGz(t, entries), root); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "pkg", "bin", "server"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "console.log(2)" {
+		t.Errorf("bin/server = %q, want the later lib/server.js", data)
+	}
+}
+
+func TestExtractZipKeepsLinksInside(t *testing.T) {
+	_, root := extractRoot(t)
+	entries := []entry{
+		{name: "lib/server.js", body: "console.log(1)"},
+		{name: "bin/server", link: "../lib/server.js", typ: tar.TypeSymlink},
+	}
+	if err := extractZip(writeZip(t, entries), root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "bin", "server")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	tests := []struct {
+		ref, want string
+	}{
+		{"https://example.com/weather-mcp-1.2.0-linux-amd64.tar.gz", "weather-mcp"},
+		{"/tmp/weather_v2.0.zip", "weather"},
+		{"https://github.com/owner/weather/archive/refs/tags/v1.2.0.tar.gz", "weather"},
+		{"https://github.com/owner/weather/archive/refs/heads/main.zip", "weather"},
+		{"https://gitlab.com/group/sub/weather/-/archive/v1.2.0/weather-v1.2.0.tar.gz", "weather"},
+		{"https://codeberg.org/owner/weather/archive/v1.2.0.tar.gz", "weather"},
+		{"https://bitbucket.org/owner/weather/get/v1.2.0.tar.gz", "weather"},
+	}
+	for _, tc := range tests {
+		got, err := Source{Kind: "archive", URL: tc.ref}.Name()
+		if err != nil {
+			t.Errorf("%s: %v", tc.ref, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s is named %q, want %q", tc.ref, got, tc.want)
+		}
+	}
+}
+
+func TestServerNameMustBeOneDirectory(t *testing.T) {
+	for _, src := range []Source{
+		{Kind: "archive", URL: "https://example.com/.tar.gz"},
+		{Kind: "archive", Path: "/tmp/.zip"},
+		{Kind: "local", Path: "/"},
+		{Kind: "go", Package: "example.com/.."},
+		{Kind: "pypi", Package: ".."},
+	} {
+		if name, err := src.Name(); err == nil {
+			t.Errorf("%+v is named %q, want an error", src, name)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "a/b", `a\b`} {
+		if _, err := serverDir(name); err == nil {
+			t.Errorf("serverDir(%q) succeeded, want an error", name)
+		}
+	}
+}
@@ -1,17 +1,23 @@
 package fetcher
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"mcpm/internal/config"
 )
 
 // Clone repo into local .mcp/servers directory
-func Clone(url string) (string, error) {
+func Clone(ctx context.Context, url string) (string, error) {
+	ctx, cancel := config.WithBuildTimeout(ctx)
+	defer cancel()
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return "", err
@@ -37,13 +43,20 @@ func Clone(url string) (string, error) {
 		return targetPath, nil
 	}
 
-	_, err = git.PlainClone(targetPath, false, &git.CloneOptions{
+	auth, err := gitAuth(url)
+	if err != nil {
+		return "", err
+	}
+	_, err = git.PlainCloneContext(ctx, targetPath, false, &git.CloneOptions{
 		URL:      url,
+		Auth:     auth,
 		Progress: nil,
 		Depth:    1,
 	})
 
 	if err != nil {
+		// Don't leave a partial checkout behind, it would be reused next time
+		os.RemoveAll(targetPath)
 		return "", fmt.Errorf("git clone failed: %w", err)
 	}
 
@@ -54,7 +67,10 @@ func Clone(url string) (string, error) {
 }
 
 // Pull updates from remote for an existing repository
-func Pull(repoPath string) error {
+func Pull(ctx context.Context, repoPath string) error {
+	ctx, cancel := config.WithBuildTimeout(ctx)
+	defer cancel()
+
 	repo, err := git.PlainOpen(repoPath)
 	if err != nil {
 		return fmt.Errorf("failed to open repository: %w", err)
@@ -65,10 +81,16 @@ func Pull(repoPath string) error {
 		return fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	err = worktree.Pull(&git.PullOptions{
+	opts := &git.PullOptions{
 		RemoteName: "origin",
 		Force:      true,
-	})
+	}
+	if remote, err := repo.Remote("origin"); err == nil && len(remote.Config().URLs) > 0 {
+		if opts.Auth, err = gitAuth(remote.Config().URLs[0]); err != nil {
+			return err
+		}
+	}
+	err = worktree.PullContext(ctx, opts)
 
 	if err != nil && err != git.NoErrAlreadyUpToDate {
 		return fmt.Errorf("git pull failed: %w", err)
@@ -77,8 +99,16 @@ func Pull(repoPath string) error {
 	return nil
 }
 
-// GetServerPath returns the path to a server by name
+// GetServerPath returns the path to a server by name. Linked servers live
+// at their local path.
 func GetServerPath(name string) (string, error) {
+	if src, err := LoadSource(name); err == nil && src.Link {
+		if _, err := os.Stat(src.Path); err != nil {
+			return "", fmt.Errorf("server '%s' is linked to %s, which is gone: %w", name, src.Path, err)
+		}
+		return src.Path, nil
+	}
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return "", err
@@ -92,7 +122,7 @@ func GetServerPath(name string) (string, error) {
 	return serverPath, nil
 }
 
-// ListServers returns a list of installed server names
+// ListServers returns a list of installed server names, including linked ones
 func ListServers() ([]string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -101,19 +131,30 @@ func ListServers() ([]string, error) {
 
 	serversDir := filepath.Join(cwd, ".mcp", "servers")
 	entries, err := os.ReadDir(serversDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []string{}, nil
-		}
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
 
-	var servers []string
+	servers := []string{}
 	for _, entry := range entries {
 		if entry.IsDir() {
 			servers = append(servers, entry.Name())
 		}
 	}
 
+	// Linked servers are built in place, outside .mcp/servers
+	state, err := loadState()
+	if err != nil {
+		return nil, err
+	}
+	var linked []string
+	for name, src := range state {
+		if src.Link {
+			linked = append(linked, name)
+		}
+	}
+	sort.Strings(linked)
+	servers = append(servers, linked...)
+
 	return servers, nil
 }
@@ -0,0 +1,143 @@
+package fetcher
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// copySkipDirs are left out when copying a local server: VCS metadata,
+// dependencies and build outputs that the build recreates
+var copySkipDirs = map[string]bool{
+	".git": true, ".mcp": true, "node_modules": true, ".venv": true, "venv": true,
+	"__pycache__": true, "target": true,
+}
+
+// ParseLocal recognises a local directory: ./path, ../path, an absolute
+// path, ~/path or file://path. ok is false for anything else.
+func ParseLocal(input string) (src Source, ok bool, err error) {
+	p := input
+	switch {
+	case strings.HasPrefix(p, "file://"):
+		p = strings.TrimPrefix(p, "file://")
+	case p == "." || p == ".." || strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../") || filepath.IsAbs(p):
+	case strings.HasPrefix(p, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Source{}, true, err
+		}
+		p = filepath.Join(home, p[2:])
+	default:
+		return Source{}, false, nil
+	}
+
+	abs, err := filepath.Abs(filepath.FromSlash(p))
+	if err != nil {
+		return Source{}, true, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return Source{}, true, fmt.Errorf("local server %s: %w", input, err)
+	}
+	if !info.IsDir() {
+		return Source{}, true, fmt.Errorf("local server %s is not a directory", input)
+	}
+	return Source{Kind: "local", Path: abs}, true, nil
+}
+
+// copyKeepDirs are carried over from a previous copy, so an update doesn't
+// reinstall every dependency
+var copyKeepDirs = []string{"node_modules", ".venv", "venv", "target"}
+
+// CopyLocal copies a local server into .mcp/servers/<name>, replacing any
+// previous copy so files deleted from the source go too, and returns its
+// path
+func CopyLocal(src Source) (string, error) {
+	name, err := src.Name()
+	if err != nil {
+		return "", err
+	}
+	target, err := serverDir(name)
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(target), "."+filepath.Base(target)+"-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := CopyTree(src.Path, tmp, copySkipDirs); err != nil {
+		return "", fmt.Errorf("failed to copy %s: %w", src.Path, err)
+	}
+	for _, name := range copyKeepDirs {
+		old := filepath.Join(target, name)
+		if info, err := os.Lstat(old); err == nil && info.IsDir() {
+			if err := os.Rename(old, filepath.Join(tmp, name)); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return "", err
+	}
+	return target, os.Chmod(target, 0755)
+}
+
+// CopyTree copies the directory from into to, keeping symlinks and leaving
+// out directories named in skip
+func CopyTree(from, to string, skip map[string]bool) error {
+	return filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(from, path)
+		dest := filepath.Join(to, rel)
+		if d.IsDir() {
+			if path != from && skip[d.Name()] {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(dest, 0755)
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			os.Remove(dest)
+			return os.Symlink(link, dest)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(path, dest)
+	})
+}
+
+// copyFile copies a regular file, keeping its permissions
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,75 @@
+package fetcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyLocalReplacesPreviousCopy(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	srcDir := filepath.Join(t.TempDir(), "weather")
+	for name, body := range map[string]string{
+		"index.js":                     "new",
+		"lib/old.js":                   "removed later",
+		"node_modules/dep/index.js":    "from the source tree",
+		".git/HEAD":                    "ref: refs/heads/main",
+		"lib/nested/node_modules/x.js": "nested dependency",
+	} {
+		path := filepath.Join(srcDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src := Source{Kind: "local", Path: srcDir}
+
+	target, err := CopyLocal(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The build installs dependencies into the copy
+	installed := filepath.Join(target, "node_modules", "dep", "index.js")
+	if err := os.MkdirAll(filepath.Dir(installed), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(installed, []byte("installed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(filepath.Join(srcDir, "lib", "old.js")); err != nil {
+		t.Fatal(err)
+	}
+	if target, err = CopyLocal(src); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, gone := range []string{"lib/old.js", ".git", "lib/nested/node_modules"} {
+		if _, err := os.Lstat(filepath.Join(target, filepath.FromSlash(gone))); err == nil {
+			t.Errorf("%s is in the copy", gone)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(target, "index.js")); err != nil || string(data) != "new" {
+		t.Errorf("index.js = %q, %v", data, err)
+	}
+	if data, err := os.ReadFile(installed); err != nil || string(data) != "installed" {
+		t.Errorf("installed dependencies were not kept: %q, %v", data, err)
+	}
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("copy mode = %v, %v", info.Mode(), err)
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(target), ".weather-*"))
+	if len(leftovers) > 0 {
+		t.Errorf("temporary copies left behind: %v", leftovers)
+	}
+}
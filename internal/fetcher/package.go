@@ -0,0 +1,150 @@
+package fetcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ParsePackage parses a package reference such as npm:@scope/pkg@1.2,
+// pypi:pkg==1.2 or go:example.com/mod/cmd/server@v1.2.
+// ok is false when input doesn't use a package scheme.
+func ParsePackage(input string) (src Source, ok bool, err error) {
+	kind, ref, found := strings.Cut(input, ":")
+	if !found {
+		return Source{}, false, nil
+	}
+	switch kind {
+	case "npm", "go":
+		// The version follows the last @, which isn't an npm scope's
+		name, version := ref, ""
+		if i := strings.LastIndex(ref, "@"); i > 0 {
+			name, version = ref[:i], ref[i+1:]
+		}
+		src = Source{Kind: kind, Package: name, Version: version}
+	case "pypi":
+		// The version is a pip specifier, e.g. pkg==1.2 or pkg[extra]>=1
+		name, version := ref, ""
+		if i := strings.IndexAny(ref, "=<>!~"); i >= 0 {
+			name, version = strings.TrimSpace(ref[:i]), ref[i:]
+		}
+		src = Source{Kind: kind, Package: name, Version: version}
+	default:
+		return Source{}, false, nil
+	}
+	if src.Package == "" {
+		return Source{}, true, fmt.Errorf("missing package name in %q", input)
+	}
+	return src, true, nil
+}
+
+// Name is the server name a source installs as: the repo or package name
+// without scope or version. It fails when that isn't usable as a directory
+// under .mcp/servers.
+func (s Source) Name() (string, error) {
+	name := s.name()
+	if err := checkServerName(name); err != nil {
+		return "", fmt.Errorf("can't name a server after %s: %w", s.origin(), err)
+	}
+	return name, nil
+}
+
+// origin is the package, URL or path the source installs from
+func (s Source) origin() string {
+	switch {
+	case s.Package != "":
+		return s.Kind + ":" + s.Package
+	case s.URL != "":
+		return s.URL
+	}
+	return s.Path
+}
+
+func (s Source) name() string {
+	ref := s.URL
+	if s.IsPackage() {
+		ref = s.Package
+	}
+	switch s.Kind {
+	case "local":
+		return filepath.Base(s.Path)
+	case "archive":
+		if s.URL != "" {
+			return archiveName(s.URL)
+		}
+		return archiveName(s.Path)
+	}
+	ref, _, _ = strings.Cut(ref, "[") // PyPI extras
+	ref = strings.TrimSuffix(strings.TrimRight(ref, "/"), ".git")
+	if s.Kind == "go" {
+		// example.com/weather/cmd/server is the weather server
+		parts := strings.Split(ref, "/")
+		if n := len(parts); n > 3 && parts[n-2] == "cmd" && genericGoCommands[parts[n-1]] {
+			parts = parts[:n-2]
+		}
+		// Skip a major version suffix, e.g. example.com/server/v2
+		if last := parts[len(parts)-1]; len(parts) > 1 && len(last) > 1 && last[0] == 'v' && strings.Trim(last[1:], "0123456789") == "" {
+			parts = parts[:len(parts)-1]
+		}
+		ref = strings.Join(parts, "/")
+	}
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// genericGoCommands are main package names that say nothing about the
+// server, so go: packages named so install under their repo's name
+var genericGoCommands = map[string]bool{
+	"server": true, "mcp": true, "mcp-server": true, "mcpserver": true,
+	"main": true, "app": true, "cli": true, "stdio": true,
+}
+
+// PackageDir creates the directory a package is installed into,
+// .mcp/servers/<name>. It fails when a different source is installed under
+// the same name, rather than replacing it.
+func PackageDir(src Source) (string, error) {
+	name, err := src.Name()
+	if err != nil {
+		return "", err
+	}
+	state, err := loadState()
+	if err != nil {
+		return "", err
+	}
+	if prev, ok := state[name]; ok && (prev.Kind != src.Kind || prev.Package != src.Package) {
+		return "", fmt.Errorf("%s is already installed from %s, remove it first", name, prev.origin())
+	}
+	dir, err := serverDir(name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// serverDir returns .mcp/servers/<name>, creating only .mcp/servers
+func serverDir(name string) (string, error) {
+	if err := checkServerName(name); err != nil {
+		return "", err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Join(cwd, ".mcp", "servers")
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return "", fmt.Errorf("failed to create .mcp directory: %w", err)
+	}
+	return filepath.Join(base, name), nil
+}
+
+// checkServerName refuses names that aren't a single directory under
+// .mcp/servers, which installs remove and replace
+func checkServerName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid server name %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package fetcher
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGoPackageName(t *testing.T) {
+	tests := []struct {
+		pkg, want string
+	}{
+		{"github.com/a/weather", "weather"},
+		{"github.com/a/weather/v2", "weather"},
+		{"github.com/a/x/cmd/weather-mcp", "weather-mcp"},
+		{"github.com/a/x/cmd/server", "x"},
+		{"github.com/b/y/cmd/server", "y"},
+		{"github.com/a/x/v3/cmd/mcp", "x"},
+		{"example.com/cmd/server", "server"},
+	}
+	for _, tc := range tests {
+		got, err := Source{Kind: "go", Package: tc.pkg}.Name()
+		if err != nil {
+			t.Errorf("%s: %v", tc.pkg, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s is named %q, want %q", tc.pkg, got, tc.want)
+		}
+	}
+}
+
+func TestPackageDirRefusesAnotherSource(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	first := Source{Kind: "go", Package: "github.com/a/weather"}
+	if _, err := PackageDir(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveSource("weather", first); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reinstalling, at any version, is fine
+	first.Version = "v1.2.0"
+	if _, err := PackageDir(first); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+	if _, err := PackageDir(Source{Kind: "go", Package: "github.com/b/weather"}); err == nil {
+		t.Error("another module replaced weather")
+	}
+	if _, err := PackageDir(Source{Kind: "npm", Package: "weather"}); err == nil {
+		t.Error("an npm package replaced weather")
+	}
+}
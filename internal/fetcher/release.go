@@ -0,0 +1,501 @@
+package fetcher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"mcpm/internal/config"
+)
+
+// releaseAsset is a file attached to a release
+type releaseAsset struct {
+	Name string
+	URL  string
+}
+
+type release struct {
+	Tag    string
+	Assets []releaseAsset
+}
+
+// releaseAPI queries one forge's releases for a repo
+type releaseAPI struct {
+	forge   string // "GitHub" or "GitLab"
+	base    string // API base URL
+	project string // owner/repo for GitHub, the escaped project path for GitLab
+	header  http.Header
+}
+
+func newReleaseAPI(repoURL string) (*releaseAPI, error) {
+	u, err := repoWebURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	schemes, err := config.Schemes()
+	if err != nil {
+		return nil, err
+	}
+	// The scheme for the host says which forge it is
+	forge, base := "", ""
+	if s, ok := forgeScheme(schemes, u.Host); ok {
+		forge, base = s.Forge, strings.TrimRight(s.API, "/")
+	}
+	if forge == "" && u.Host == hostOf(config.GitHubAPI()) {
+		forge = "github"
+	}
+
+	project := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	api := &releaseAPI{header: http.Header{}}
+	switch forge {
+	case "github":
+		if base == "" && (u.Host == "github.com" || u.Host == hostOf(config.GitHubAPI())) {
+			base = config.GitHubAPI()
+		} else if base == "" {
+			// GitHub Enterprise Server
+			base = u.Scheme + "://" + u.Host + "/api/v3"
+		}
+		api.forge, api.base, api.project = "GitHub", base, project
+		api.header.Set("Accept", "application/vnd.github+json")
+		if token := releaseToken("GITHUB_TOKEN", u.String()); token != "" {
+			api.header.Set("Authorization", "Bearer "+token)
+		}
+	case "gitlab":
+		if base == "" {
+			base = u.Scheme + "://" + u.Host + "/api/v4"
+		}
+		api.forge, api.base, api.project = "GitLab", base, url.PathEscape(project)
+		if token := releaseToken("GITLAB_TOKEN", u.String()); token != "" {
+			api.header.Set("PRIVATE-TOKEN", token)
+		}
+	default:
+		return nil, fmt.Errorf("releases are only supported for GitHub and GitLab repos, add a scheme with forge: github or gitlab for %s", u.Host)
+	}
+	return api, nil
+}
+
+// repoWebURL is the forge's web URL for a clone URL. SSH clone URLs,
+// ssh://git@host/path or git@host:path, map to https on the same host;
+// the SSH port and user are dropped.
+func repoWebURL(repoURL string) (*url.URL, error) {
+	if user, rest, ok := strings.Cut(repoURL, "@"); ok && !strings.Contains(user, "/") && !strings.Contains(repoURL, "://") {
+		host, repoPath, _ := strings.Cut(rest, ":")
+		repoURL = "ssh://" + host + "/" + strings.TrimPrefix(repoPath, "/")
+	}
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("releases need an http(s) or ssh repo URL, got %s", repoURL)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return &url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}, nil
+	case "ssh", "git+ssh":
+		return &url.URL{Scheme: "https", Host: u.Hostname(), Path: u.Path}, nil
+	}
+	return nil, fmt.Errorf("releases need an http(s) or ssh repo URL, got %s", repoURL)
+}
+
+// releaseToken is the API token from env, or else the credentials of the
+// scheme the repo at webURL is on
+func releaseToken(env, webURL string) string {
+	if token := os.Getenv(env); token != "" {
+		return token
+	}
+	_, token, _ := urlCredentials(webURL)
+	return token
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// get fetches an API URL. found is false on 404.
+func (a *releaseAPI) get(ctx context.Context, apiURL string, header http.Header) (body []byte, found bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	for key, values := range a.header {
+		req.Header[key] = values
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s API request failed: %w", a.forge, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("%s API %s returned %s", a.forge, apiURL, resp.Status)
+	}
+	body, err = io.ReadAll(resp.Body)
+	return body, err == nil, err
+}
+
+// release returns the release tagged tag, or the latest one for "" or "latest"
+func (a *releaseAPI) release(ctx context.Context, tag string) (*release, error) {
+	latest := tag == "" || tag == "latest"
+	var endpoint string
+	switch {
+	case a.forge == "GitHub" && latest:
+		endpoint = fmt.Sprintf("%s/repos/%s/releases/latest", a.base, a.project)
+	case a.forge == "GitHub":
+		endpoint = fmt.Sprintf("%s/repos/%s/releases/tags/%s", a.base, a.project, url.PathEscape(tag))
+	case latest:
+		endpoint = fmt.Sprintf("%s/projects/%s/releases/permalink/latest", a.base, a.project)
+	default:
+		endpoint = fmt.Sprintf("%s/projects/%s/releases/%s", a.base, a.project, url.PathEscape(tag))
+	}
+
+	body, found, err := a.get(ctx, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		if latest {
+			return nil, fmt.Errorf("no %s release found for %s", a.forge, a.project)
+		}
+		return nil, fmt.Errorf("no %s release tagged %s", a.forge, tag)
+	}
+
+	rel := &release{}
+	if a.forge == "GitHub" {
+		var r struct {
+			TagName string `json:"tag_name"`
+			Assets  []struct {
+				Name        string `json:"name"`
+				URL         string `json:"url"`
+				DownloadURL string `json:"browser_download_url"`
+			} `json:"assets"`
+		}
+		if err := json.Unmarshal(body, &r); err != nil {
+			return nil, fmt.Errorf("invalid GitHub release: %w", err)
+		}
+		rel.Tag = r.TagName
+		for _, asset := range r.Assets {
+			// The API URL serves private repos' assets too, given the token
+			assetURL := asset.URL
+			if assetURL == "" {
+				assetURL = asset.DownloadURL
+			}
+			rel.Assets = append(rel.Assets, releaseAsset{Name: asset.Name, URL: assetURL})
+		}
+	} else {
+		var r struct {
+			TagName string `json:"tag_name"`
+			Assets  struct {
+				Links []struct {
+					Name           string `json:"name"`
+					URL            string `json:"url"`
+					DirectAssetURL string `json:"direct_asset_url"`
+				} `json:"links"`
+			} `json:"assets"`
+		}
+		if err := json.Unmarshal(body, &r); err != nil {
+			return nil, fmt.Errorf("invalid GitLab release: %w", err)
+		}
+		rel.Tag = r.TagName
+		for _, link := range r.Assets.Links {
+			assetURL := link.DirectAssetURL
+			if assetURL == "" {
+				assetURL = link.URL
+			}
+			rel.Assets = append(rel.Assets, releaseAsset{Name: link.Name, URL: assetURL})
+		}
+	}
+	return rel, nil
+}
+
+// download fetches a release asset. The token only goes to the API's own
+// host, so links to other sites don't see it.
+func (a *releaseAPI) download(ctx context.Context, asset releaseAsset) (*os.File, error) {
+	header := http.Header{}
+	if hostOf(asset.URL) == hostOf(a.base) {
+		for key, values := range a.header {
+			header[key] = values
+		}
+	}
+	if a.forge == "GitHub" {
+		header.Set("Accept", "application/octet-stream")
+	}
+	return download(ctx, asset.URL, header)
+}
+
+// file returns a file of the repo at ref, or nil when there is none
+func (a *releaseAPI) file(ctx context.Context, ref, name string) ([]byte, error) {
+	if a.forge == "GitHub" {
+		endpoint := fmt.Sprintf("%s/repos/%s/contents/%s?ref=%s", a.base, a.project, name, url.QueryEscape(ref))
+		body, _, err := a.get(ctx, endpoint, http.Header{"Accept": {"application/vnd.github.raw"}})
+		return body, err
+	}
+	endpoint := fmt.Sprintf("%s/projects/%s/repository/files/%s/raw?ref=%s", a.base, a.project, url.PathEscape(name), url.QueryEscape(ref))
+	body, _, err := a.get(ctx, endpoint, nil)
+	return body, err
+}
+
+// platformAliases are the names releases use for each GOOS and GOARCH,
+// most specific first
+var (
+	osAliases = []platformAlias{
+		{"darwin", []string{"darwin", "macos", "mac", "osx", "apple"}},
+		{"windows", []string{"windows", "win64", "win32", "win"}},
+		{"linux", []string{"linux"}},
+		{"freebsd", []string{"freebsd"}},
+	}
+	archAliases = []platformAlias{
+		{"amd64", []string{"amd64", "x86_64", "x86-64", "x64"}},
+		{"arm64", []string{"arm64", "aarch64"}},
+		{"386", []string{"386", "i386", "i686", "x86"}},
+		{"arm", []string{"armv7", "armv6", "armhf", "arm"}},
+		{"universal", []string{"universal", "all"}}, // macOS fat binaries
+	}
+)
+
+type platformAlias struct {
+	name    string
+	aliases []string
+}
+
+// platformOf returns the first platform whose alias appears as a word in name
+func platformOf(name string, table []platformAlias) string {
+	for _, p := range table {
+		for _, alias := range p.aliases {
+			re := regexp.MustCompile(`(^|[^a-z0-9])` + regexp.QuoteMeta(alias) + `([^a-z0-9]|$)`)
+			if re.MatchString(name) {
+				return p.name
+			}
+		}
+	}
+	return ""
+}
+
+// nonBinarySuffixes are release assets that can't be the server itself
+var nonBinarySuffixes = []string{
+	".sha256", ".sha256sum", ".sha512", ".md5", ".sig", ".asc", ".pem", ".sbom", ".spdx",
+	".json", ".jsonl", ".txt", ".md", ".deb", ".rpm", ".apk", ".msi", ".pkg", ".dmg",
+}
+
+// isChecksumAsset reports whether an asset lists checksums of the others
+func isChecksumAsset(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.Contains(lower, "checksums") || strings.Contains(lower, "sha256sums")
+}
+
+// pickAsset chooses the asset for goos/goarch: through the mapping from
+// mcp.json's "assets" when there is one, or else by the platform names in
+// the asset names
+func pickAsset(rel *release, goos, goarch string, mapping map[string]string) (*releaseAsset, error) {
+	if len(mapping) > 0 {
+		pattern, ok := mapping[goos+"/"+goarch]
+		if !ok {
+			return nil, fmt.Errorf("mcp.json assets has no entry for %s/%s", goos, goarch)
+		}
+		version := strings.TrimPrefix(rel.Tag, "v")
+		pattern = strings.NewReplacer("{tag}", rel.Tag, "{version}", version).Replace(pattern)
+		for i, asset := range rel.Assets {
+			if ok, _ := path.Match(pattern, asset.Name); ok {
+				return &rel.Assets[i], nil
+			}
+		}
+		return nil, fmt.Errorf("release %s has no asset matching %q from mcp.json", rel.Tag, pattern)
+	}
+
+	var best *releaseAsset
+	bestScore := 0
+	var names []string
+	for i, asset := range rel.Assets {
+		names = append(names, asset.Name)
+		lower := strings.ToLower(asset.Name)
+		if isChecksumAsset(lower) || hasAnySuffix(lower, nonBinarySuffixes) {
+			continue
+		}
+		if goos == "windows" && archiveExt(lower) == "" && !strings.HasSuffix(lower, ".exe") {
+			continue
+		}
+		if platformOf(lower, osAliases) != goos {
+			continue
+		}
+		score := 0
+		switch platformOf(lower, archAliases) {
+		case goarch:
+			score = 2
+		case "universal", "":
+			score = 1
+		}
+		if score > bestScore {
+			best, bestScore = &rel.Assets[i], score
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("release %s has no asset for %s/%s (assets: %s)", rel.Tag, goos, goarch, strings.Join(names, ", "))
+	}
+	return best, nil
+}
+
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// publishedChecksum returns the SHA-256 of asset from the release's
+// checksum files: <asset>.sha256, or a checksums.txt / SHA256SUMS style
+// list. Returns "" when none covers the asset.
+func (a *releaseAPI) publishedChecksum(ctx context.Context, rel *release, asset *releaseAsset) (string, error) {
+	for _, sums := range rel.Assets {
+		if sums.Name != asset.Name+".sha256" && sums.Name != asset.Name+".sha256sum" {
+			continue
+		}
+		body, err := a.fetchText(ctx, sums)
+		if err != nil {
+			return "", err
+		}
+		if fields := strings.Fields(body); len(fields) > 0 {
+			return fields[0], nil
+		}
+	}
+	for _, sums := range rel.Assets {
+		if !isChecksumAsset(sums.Name) {
+			continue
+		}
+		body, err := a.fetchText(ctx, sums)
+		if err != nil {
+			return "", err
+		}
+		scanner := bufio.NewScanner(strings.NewReader(body))
+		for scanner.Scan() {
+			// "<hex>  <name>", or "<hex> *<name>" for binary mode
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset.Name {
+				return fields[0], nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func (a *releaseAPI) fetchText(ctx context.Context, asset releaseAsset) (string, error) {
+	file, err := a.download(ctx, asset)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, io.LimitReader(file, 1<<20))
+	return buf.String(), err
+}
+
+// FetchRelease downloads the release asset for this platform into
+// .mcp/servers/<name>, verifying it against published checksums, and
+// returns the path and the release's tag. src.Version is the tag to
+// install, "latest" when empty.
+func FetchRelease(ctx context.Context, src Source) (string, string, error) {
+	ctx, cancel := config.WithBuildTimeout(ctx)
+	defer cancel()
+
+	name, err := src.Name()
+	if err != nil {
+		return "", "", err
+	}
+	api, err := newReleaseAPI(src.URL)
+	if err != nil {
+		return "", "", err
+	}
+	rel, err := api.release(ctx, src.Version)
+	if err != nil {
+		return "", "", err
+	}
+
+	// The repo's mcp.json may map platforms to asset names
+	var mapping map[string]string
+	if data, err := api.file(ctx, rel.Tag, "mcp.json"); err == nil && data != nil {
+		var m struct {
+			Assets map[string]string `json:"assets"`
+		}
+		if err := json.Unmarshal(data, &m); err == nil {
+			mapping = m.Assets
+		}
+	}
+	asset, err := pickAsset(rel, runtime.GOOS, runtime.GOARCH, mapping)
+	if err != nil {
+		return "", "", err
+	}
+
+	file, err := api.download(ctx, *asset)
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	sum, err := api.publishedChecksum(ctx, rel, asset)
+	if err != nil {
+		return "", "", fmt.Errorf("could not read checksums: %w", err)
+	}
+	if sum != "" {
+		if err := verifySHA256(file, sum); err != nil {
+			return "", "", fmt.Errorf("%s: %w", asset.Name, err)
+		}
+	}
+
+	target, err := serverDir(name)
+	if err != nil {
+		return "", "", err
+	}
+	if archiveExt(asset.Name) != "" {
+		err = installArchive(file, asset.Name, target)
+	} else {
+		err = installBinary(file, name, target)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return target, rel.Tag, nil
+}
+
+// installBinary replaces target with a directory holding just the
+// executable, named after the server
+func installBinary(file *os.File, name, target string) error {
+	tmp, err := os.MkdirTemp(filepath.Dir(target), "."+filepath.Base(target)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if err := writeEntry(filepath.Join(tmp, name), file, 0755); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return err
+	}
+	return os.Chmod(target, 0755)
+}
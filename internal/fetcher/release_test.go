@@ -0,0 +1,360 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"mcpm/internal/config"
+)
+
+// fakeGitHub serves the parts of the GitHub releases API FetchRelease uses
+// for the repo o/weather
+type fakeGitHub struct {
+	latest   string
+	releases map[string][]fakeAsset // by tag
+	mcpJSON  string
+	token    string // required as a Bearer token when set
+
+	external *httptest.Server // hosts assets with external set
+	srv      *httptest.Server
+	requests []*http.Request
+}
+
+type fakeAsset struct {
+	name     string
+	body     string
+	external bool // linked from another host
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	t.Helper()
+	f := &fakeGitHub{releases: map[string][]fakeAsset{}}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	f.external = httptest.NewServer(http.HandlerFunc(f.serveExternal))
+	t.Cleanup(f.external.Close)
+
+	config.SetDefaults()
+	old := viper.GetString(config.KeyGitHubAPI)
+	viper.Set(config.KeyGitHubAPI, f.srv.URL)
+	t.Cleanup(func() { viper.Set(config.KeyGitHubAPI, old) })
+
+	// FetchRelease installs into .mcp/servers under the working directory
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+	return f
+}
+
+func (f *fakeGitHub) source(version string) Source {
+	return Source{Kind: "release", URL: f.srv.URL + "/o/weather", Version: version}
+}
+
+func (f *fakeGitHub) serve(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r)
+	if f.token != "" && r.Header.Get("Authorization") != "Bearer "+f.token {
+		http.NotFound(w, r) // as GitHub answers for private repos
+		return
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, "/repos/o/weather/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case rest == "releases/latest":
+		f.writeRelease(w, r, f.latest)
+	case strings.HasPrefix(rest, "releases/tags/"):
+		f.writeRelease(w, r, strings.TrimPrefix(rest, "releases/tags/"))
+	case strings.HasPrefix(rest, "releases/assets/"):
+		if r.Header.Get("Accept") != "application/octet-stream" {
+			http.Error(w, "asset metadata", http.StatusTeapot)
+			return
+		}
+		tag, name, _ := strings.Cut(strings.TrimPrefix(rest, "releases/assets/"), "/")
+		f.writeAsset(w, r, tag, name)
+	case rest == "contents/mcp.json" && f.mcpJSON != "":
+		fmt.Fprint(w, f.mcpJSON)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeGitHub) serveExternal(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r)
+	tag, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	f.writeAsset(w, r, tag, name)
+}
+
+func (f *fakeGitHub) writeRelease(w http.ResponseWriter, r *http.Request, tag string) {
+	assets, ok := f.releases[tag]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	type asset struct {
+		Name        string `json:"name"`
+		URL         string `json:"url"`
+		DownloadURL string `json:"browser_download_url"`
+	}
+	rel := struct {
+		TagName string  `json:"tag_name"`
+		Assets  []asset `json:"assets"`
+	}{TagName: tag, Assets: []asset{}}
+	for _, a := range assets {
+		apiURL := fmt.Sprintf("%s/repos/o/weather/releases/assets/%s/%s", f.srv.URL, tag, a.name)
+		if a.external {
+			apiURL = fmt.Sprintf("%s/%s/%s", f.external.URL, tag, a.name)
+		}
+		// browser_download_url needs a session for private repos, so it
+		// never serves anything here
+		rel.Assets = append(rel.Assets, asset{Name: a.name, URL: apiURL, DownloadURL: f.srv.URL + "/download/" + a.name})
+	}
+	json.NewEncoder(w).Encode(rel)
+}
+
+func (f *fakeGitHub) writeAsset(w http.ResponseWriter, r *http.Request, tag, name string) {
+	for _, a := range f.releases[tag] {
+		if a.name == name {
+			fmt.Fprint(w, a.body)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// platformAsset is an asset name FetchRelease picks on this machine;
+// otherAsset is one for a different OS
+func platformAsset(name string) string {
+	asset := fmt.Sprintf("%s_%s_%s", name, runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		asset += ".exe"
+	}
+	return asset
+}
+
+func otherAsset(name string) string {
+	if runtime.GOOS == "darwin" {
+		return fmt.Sprintf("%s_linux_%s", name, runtime.GOARCH)
+	}
+	return fmt.Sprintf("%s_darwin_%s", name, runtime.GOARCH)
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func installedBinary(t *testing.T, target string) string {
+	t.Helper()
+	name := "weather"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	data, err := os.ReadFile(filepath.Join(target, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFetchReleaseChoosesLatestAndPinnedTag(t *testing.T) {
+	f := newFakeGitHub(t)
+	f.latest = "v2.0.0"
+	for _, tag := range []string{"v1.0.0", "v2.0.0"} {
+		f.releases[tag] = []fakeAsset{
+			{name: otherAsset("weather"), body: "other platform " + tag},
+			{name: platformAsset("weather"), body: "binary " + tag},
+			{name: "weather.deb", body: "package " + tag},
+		}
+	}
+
+	tests := []struct {
+		version, wantTag string
+	}{
+		{"", "v2.0.0"},
+		{"latest", "v2.0.0"},
+		{"v1.0.0", "v1.0.0"},
+	}
+	for _, tc := range tests {
+		target, tag, err := FetchRelease(context.Background(), f.source(tc.version))
+		if err != nil {
+			t.Fatalf("version %q: %v", tc.version, err)
+		}
+		if tag != tc.wantTag {
+			t.Errorf("version %q: tag = %s, want %s", tc.version, tag, tc.wantTag)
+		}
+		if got, want := installedBinary(t, target), "binary "+tc.wantTag; got != want {
+			t.Errorf("version %q: installed %q, want %q", tc.version, got, want)
+		}
+	}
+
+	if _, _, err := FetchRelease(context.Background(), f.source("v9.9.9")); err == nil || !strings.Contains(err.Error(), "no GitHub release tagged v9.9.9") {
+		t.Errorf("missing tag: err = %v", err)
+	}
+}
+
+func TestFetchReleaseUsesAssetMapping(t *testing.T) {
+	f := newFakeGitHub(t)
+	f.latest = "v2.0.0"
+	f.releases["v2.0.0"] = []fakeAsset{
+		{name: platformAsset("weather"), body: "guessed"},
+		{name: "weather-2.0.0-mapped.bin", body: "mapped"},
+	}
+	f.mcpJSON = fmt.Sprintf(`{"assets": {%q: "weather-{version}-mapped.*"}}`, runtime.GOOS+"/"+runtime.GOARCH)
+
+	target, _, err := FetchRelease(context.Background(), f.source(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := installedBinary(t, target); got != "mapped" {
+		t.Errorf("installed %q, want the asset mcp.json maps this platform to", got)
+	}
+
+	f.mcpJSON = `{"assets": {"plan9/mips": "weather-plan9"}}`
+	if _, _, err := FetchRelease(context.Background(), f.source("")); err == nil || !strings.Contains(err.Error(), "no entry for") {
+		t.Errorf("unmapped platform: err = %v", err)
+	}
+}
+
+func TestFetchReleaseVerifiesChecksums(t *testing.T) {
+	asset := platformAsset("weather")
+	const body = "binary"
+	tests := []struct {
+		name    string
+		sums    []fakeAsset
+		wantErr bool
+	}{
+		{"asset.sha256", []fakeAsset{{name: asset + ".sha256", body: sha256Hex(body) + "  " + asset + "\n"}}, false},
+		{"checksums.txt", []fakeAsset{{name: "weather_checksums.txt", body: fmt.Sprintf("%s  %s\n%s *%s\n", sha256Hex("other"), otherAsset("weather"), sha256Hex(body), asset)}}, false},
+		{"asset.sha256 mismatch", []fakeAsset{{name: asset + ".sha256", body: sha256Hex("tampered")}}, true},
+		{"checksums.txt mismatch", []fakeAsset{{name: "SHA256SUMS", body: sha256Hex("tampered") + "  " + asset + "\n"}}, true},
+		{"checksums.txt without the asset", []fakeAsset{{name: "checksums.txt", body: sha256Hex("other") + "  " + otherAsset("weather") + "\n"}}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeGitHub(t)
+			f.latest = "v1.0.0"
+			f.releases["v1.0.0"] = append([]fakeAsset{{name: asset, body: body}}, tc.sums...)
+
+			target, _, err := FetchRelease(context.Background(), f.source(""))
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+					t.Fatalf("err = %v, want a checksum mismatch", err)
+				}
+				if _, err := os.Stat(filepath.Join(".mcp", "servers", "weather")); err == nil {
+					t.Fatal("a binary with the wrong checksum was installed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := installedBinary(t, target); got != body {
+				t.Errorf("installed %q, want %q", got, body)
+			}
+		})
+	}
+}
+
+func TestFetchReleaseSendsToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "secret")
+	f := newFakeGitHub(t)
+	f.token = "secret"
+	f.latest = "v1.0.0"
+	asset := platformAsset("weather")
+	f.releases["v1.0.0"] = []fakeAsset{
+		{name: asset, body: "private binary"},
+		{name: "checksums.txt", body: sha256Hex("private binary") + "  " + asset + "\n", external: true},
+	}
+
+	target, _, err := FetchRelease(context.Background(), f.source(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := installedBinary(t, target); got != "private binary" {
+		t.Errorf("installed %q, want the private asset", got)
+	}
+	for _, r := range f.requests {
+		external := r.Host == strings.TrimPrefix(f.external.URL, "http://")
+		if auth := r.Header.Get("Authorization"); external && auth != "" {
+			t.Errorf("%s on another host got the token", r.URL.Path)
+		} else if !external && auth != "Bearer secret" {
+			t.Errorf("%s: Authorization = %q", r.URL.Path, auth)
+		}
+	}
+}
+
+func TestNewReleaseAPI(t *testing.T) {
+	config.SetDefaults()
+	t.Setenv("GITHUB_TOKEN", "gh-secret")
+	t.Setenv("GITLAB_TOKEN", "gl-secret")
+	setSchemes(t, map[string]interface{}{
+		"acme":   map[string]interface{}{"url": "https://git.acme.dev/{path}.git", "forge": "gitlab"},
+		"lab":    map[string]interface{}{"url": "http://git.lab.internal:8080/{path}.git", "forge": "gitlab"},
+		"ghe":    map[string]interface{}{"url": "https://code.corp.dev/{path}.git", "forge": "github"},
+		"ghe:eu": map[string]interface{}{"url": "https://eu.corp.dev/{path}.git", "forge": "github", "api": "https://api.eu.corp.dev/"},
+	})
+	tests := []struct {
+		repoURL, base, project string
+		header, token          string
+	}{
+		{"https://github.com/o/weather.git", "https://api.github.com", "o/weather", "Authorization", "Bearer gh-secret"},
+		{"ssh://git@github.com/o/weather.git", "https://api.github.com", "o/weather", "Authorization", "Bearer gh-secret"},
+		{"https://gitlab.com/group/sub/weather", "https://gitlab.com/api/v4", "group%2Fsub%2Fweather", "PRIVATE-TOKEN", "gl-secret"},
+		{"https://gitlab.cee.redhat.com/group/weather", "https://gitlab.cee.redhat.com/api/v4", "group%2Fweather", "PRIVATE-TOKEN", "gl-secret"},
+		// Self-hosted forges are known by their scheme, not their name
+		{"ssh://git@git.acme.dev:2222/group/weather.git", "https://git.acme.dev/api/v4", "group%2Fweather", "PRIVATE-TOKEN", "gl-secret"},
+		{"git@git.acme.dev:group/weather.git", "https://git.acme.dev/api/v4", "group%2Fweather", "PRIVATE-TOKEN", "gl-secret"},
+		{"http://git.lab.internal:8080/group/weather", "http://git.lab.internal:8080/api/v4", "group%2Fweather", "PRIVATE-TOKEN", "gl-secret"},
+		{"https://code.corp.dev/o/weather", "https://code.corp.dev/api/v3", "o/weather", "Authorization", "Bearer gh-secret"},
+		{"https://eu.corp.dev/o/weather", "https://api.eu.corp.dev", "o/weather", "Authorization", "Bearer gh-secret"},
+	}
+	for _, tc := range tests {
+		api, err := newReleaseAPI(tc.repoURL)
+		if err != nil {
+			t.Errorf("%s: %v", tc.repoURL, err)
+			continue
+		}
+		if api.base != tc.base || api.project != tc.project {
+			t.Errorf("%s: base, project = %s, %s, want %s, %s", tc.repoURL, api.base, api.project, tc.base, tc.project)
+		}
+		if got := api.header.Get(tc.header); got != tc.token {
+			t.Errorf("%s: %s = %q, want %q", tc.repoURL, tc.header, got, tc.token)
+		}
+	}
+
+	for _, repoURL := range []string{"https://codeberg.org/o/weather", "https://gitlab.example.com/o/weather", "file:///srv/weather"} {
+		if _, err := newReleaseAPI(repoURL); err == nil {
+			t.Errorf("%s: want an error", repoURL)
+		}
+	}
+}
+
+func TestSchemeForgeSettings(t *testing.T) {
+	for _, scheme := range []map[string]interface{}{
+		{"url": "https://git.acme.dev/{path}.git", "forge": "gitea"},
+		{"url": "https://git.acme.dev/{path}.git", "forge": "gitlab", "api": "http://git.acme.dev/api/v4", "credentials": "env:ACME_TOKEN"},
+	} {
+		setSchemes(t, map[string]interface{}{"acme": scheme})
+		if _, err := config.Schemes(); err == nil {
+			t.Errorf("%v: want an error", scheme)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"mcpm/internal/config"
+)
+
+// schemeRe matches <prefix>:@<path>, where the prefix may itself contain
+// colons and a host, e.g. gl:rh:@org/repo or gitea:git.example.com:@org/repo
+var schemeRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_.:-]*):@(.+)$`)
+
+// ExpandScheme turns a shorthand like @org/repo or gl:@org/repo into the
+// URL to clone. Anything else, e.g. a direct URL, is returned unchanged.
+func ExpandScheme(input string) (string, error) {
+	prefix, path := "gh", strings.TrimPrefix(input, "@")
+	if m := schemeRe.FindStringSubmatch(input); m != nil {
+		prefix, path = m[1], m[2]
+	} else if !strings.HasPrefix(input, "@") {
+		return input, nil
+	}
+
+	schemes, err := config.Schemes()
+	if err != nil {
+		return "", err
+	}
+	s, ok := findScheme(schemes, prefix)
+	if !ok {
+		return "", fmt.Errorf("unknown scheme %s: (see mcpm scheme list)", prefix)
+	}
+	if path, err = layoutPath(s.Layout, path); err != nil {
+		return "", fmt.Errorf("%s:@ %w", prefix, err)
+	}
+	return SchemeURL(s, path), nil
+}
+
+// findScheme returns the scheme for prefix. An exact prefix wins over a
+// host pattern, so gitea:work can name one host of gitea:{host}.
+func findScheme(schemes []config.Scheme, prefix string) (config.Scheme, bool) {
+	for _, s := range schemes {
+		if s.Prefix == prefix {
+			return s, true
+		}
+	}
+	for _, s := range schemes {
+		base, ok := strings.CutSuffix(s.Prefix, ":{host}")
+		if !ok {
+			continue
+		}
+		if host, ok := strings.CutPrefix(prefix, base+":"); ok && host != "" && !strings.Contains(host, ":") {
+			s.URL = strings.ReplaceAll(s.URL, "{host}", host)
+			return s, true
+		}
+	}
+	return config.Scheme{}, false
+}
+
+// layoutPath checks a repo path against the forge's layout: GitHub-like
+// forges have owner/repo, GitLab nests groups, and sourcehut has
+// ~user/repo (the ~ may be left out)
+func layoutPath(layout, path string) (string, error) {
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	parts := strings.Split(path, "/")
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			return "", fmt.Errorf("invalid repo path %q", path)
+		}
+	}
+	switch layout {
+	case "owner/repo":
+		if len(parts) != 2 {
+			return "", fmt.Errorf("expects owner/repo, got %q", path)
+		}
+	case "sourcehut":
+		if len(parts) != 2 {
+			return "", fmt.Errorf("expects ~user/repo, got %q", path)
+		}
+		if !strings.HasPrefix(parts[0], "~") {
+			parts[0] = "~" + parts[0]
+		}
+	default:
+		if len(parts) < 2 {
+			return "", fmt.Errorf("expects group/repo, got %q", path)
+		}
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// SchemeURL is the clone URL of path under s, over SSH when the scheme's
+// protocol says so
+func SchemeURL(s config.Scheme, path string) string {
+	cloneURL := strings.ReplaceAll(s.URL, "{path}", path)
+	if s.Protocol != "ssh" {
+		return cloneURL
+	}
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return cloneURL
+	}
+	return "ssh://git@" + u.Hostname() + u.Path
+}
+
+// gitAuth returns the credentials of the scheme whose host the repo is
+// on, for https clones. SSH clones use the SSH agent.
+func gitAuth(repoURL string) (transport.AuthMethod, error) {
+	username, token, err := urlCredentials(repoURL)
+	if err != nil || token == "" {
+		return nil, err
+	}
+	return &githttp.BasicAuth{Username: username, Password: token}, nil
+}
+
+// urlCredentials returns the token configured for the scheme whose host
+// rawURL is on, if any. Tokens are only sent over https.
+func urlCredentials(rawURL string) (username, token string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" {
+		return "", "", nil
+	}
+	schemes, err := config.Schemes()
+	if err != nil {
+		return "", "", err
+	}
+	s, ok := credentialScheme(schemes, u.Host)
+	if !ok {
+		return "", "", nil
+	}
+	if token, err = schemeToken(s.Credentials); err != nil {
+		return "", "", err
+	}
+	username = s.Username
+	if username == "" {
+		username = "oauth2"
+	}
+	return username, token, nil
+}
+
+// credentialScheme returns the scheme with credentials for host. A scheme
+// naming the host wins over a host pattern like gitea:{host}, so the
+// pattern's token never goes to a host another scheme covers.
+func credentialScheme(schemes []config.Scheme, host string) (config.Scheme, bool) {
+	named := false
+	for _, s := range schemes {
+		if strings.Contains(s.URL, "{host}") || hostOf(s.URL) != host {
+			continue
+		}
+		if s.Credentials != "" {
+			return s, true
+		}
+		named = true
+	}
+	if named {
+		return config.Scheme{}, false
+	}
+	for _, s := range schemes {
+		if s.Credentials != "" && strings.Contains(s.URL, "{host}") && hostOf(strings.ReplaceAll(s.URL, "{host}", host)) == host {
+			return s, true
+		}
+	}
+	return config.Scheme{}, false
+}
+
+// forgeScheme returns the scheme that says which forge host is, one naming
+// the host before a host pattern like gitea:{host}
+func forgeScheme(schemes []config.Scheme, host string) (config.Scheme, bool) {
+	for _, s := range schemes {
+		if s.Forge != "" && !strings.Contains(s.URL, "{host}") && hostOf(s.URL) == host {
+			return s, true
+		}
+	}
+	for _, s := range schemes {
+		if s.Forge != "" && strings.Contains(s.URL, "{host}") && hostOf(strings.ReplaceAll(s.URL, "{host}", host)) == host {
+			s.API = strings.ReplaceAll(s.API, "{host}", host)
+			return s, true
+		}
+	}
+	return config.Scheme{}, false
+}
+
+// schemeToken reads a credentials reference: env:NAME or file:PATH
+func schemeToken(ref string) (string, error) {
+	kind, value, _ := strings.Cut(ref, ":")
+	switch kind {
+	case "env":
+		return os.Getenv(value), nil
+	case "file":
+		if strings.HasPrefix(value, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			value = filepath.Join(home, value[2:])
+		}
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return "", fmt.Errorf("could not read credentials: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return "", fmt.Errorf("credentials must be env:NAME or file:PATH, not %s", ref)
+}
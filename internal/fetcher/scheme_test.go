@@ -0,0 +1,90 @@
+package fetcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"mcpm/internal/config"
+)
+
+func setSchemes(t *testing.T, schemes map[string]interface{}) {
+	t.Helper()
+	viper.Set(config.KeySchemes, schemes)
+	t.Cleanup(func() { viper.Set(config.KeySchemes, nil) })
+}
+
+func TestURLCredentials(t *testing.T) {
+	t.Setenv("GITEA_TOKEN", "gitea-secret")
+	t.Setenv("WORK_TOKEN", "work-secret")
+	setSchemes(t, map[string]interface{}{
+		"gitea:{host}": map[string]interface{}{"credentials": "env:GITEA_TOKEN"},
+		"work":         map[string]interface{}{"url": "https://git.work.dev/{path}.git", "credentials": "env:WORK_TOKEN", "username": "bot"},
+		"plain":        map[string]interface{}{"url": "https://git.plain.dev/{path}.git"},
+	})
+
+	tests := []struct {
+		url, username, token string
+	}{
+		{"https://git.work.dev/team/weather.git", "bot", "work-secret"},
+		// Pattern schemes match the host they expand to
+		{"https://gitea.example.com/owner/weather.git", "oauth2", "gitea-secret"},
+		// but not hosts another scheme names, with or without credentials
+		{"https://github.com/owner/weather.git", "", ""},
+		{"https://git.plain.dev/owner/weather.git", "", ""},
+		// Tokens never go over plain http or to SSH
+		{"http://git.work.dev/team/weather.git", "", ""},
+		{"http://gitea.example.com/owner/weather.git", "", ""},
+		{"ssh://git@git.work.dev/team/weather.git", "", ""},
+	}
+	for _, tc := range tests {
+		username, token, err := urlCredentials(tc.url)
+		if err != nil {
+			t.Errorf("%s: %v", tc.url, err)
+			continue
+		}
+		if username != tc.username || token != tc.token {
+			t.Errorf("%s: credentials %q, %q, want %q, %q", tc.url, username, token, tc.username, tc.token)
+		}
+	}
+}
+
+func TestSchemesRejectCredentialsOverHTTP(t *testing.T) {
+	setSchemes(t, map[string]interface{}{
+		"lab": map[string]interface{}{"url": "http://git.lab.dev/{path}.git", "credentials": "env:LAB_TOKEN"},
+	})
+	if _, err := config.Schemes(); err == nil || !strings.Contains(err.Error(), "https") {
+		t.Errorf("err = %v, want credentials over http refused", err)
+	}
+	if _, _, err := urlCredentials("https://git.lab.dev/x/y.git"); err == nil {
+		t.Error("urlCredentials used an invalid scheme")
+	}
+}
+
+func TestExpandScheme(t *testing.T) {
+	setSchemes(t, nil)
+	tests := []struct {
+		input, want string
+	}{
+		{"@owner/weather", "https://github.com/owner/weather.git"},
+		{"gl:@group/sub/weather", "https://gitlab.com/group/sub/weather.git"},
+		{"srht:@user/weather", "https://git.sr.ht/~user/weather"},
+		{"gitea:git.example.com:@owner/weather", "https://git.example.com/owner/weather.git"},
+		{"https://example.com/weather.git", "https://example.com/weather.git"},
+	}
+	for _, tc := range tests {
+		got, err := ExpandScheme(tc.input)
+		if err != nil {
+			t.Errorf("%s: %v", tc.input, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s expands to %s, want %s", tc.input, got, tc.want)
+		}
+	}
+	for _, input := range []string{"nope:@owner/weather", "gh:@owner", "gh:@owner/../weather"} {
+		if _, err := ExpandScheme(input); err == nil {
+			t.Errorf("%s: want an error", input)
+		}
+	}
+}
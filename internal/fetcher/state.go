@@ -0,0 +1,90 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Source records where an installed server came from, so update can fetch
+// it the same way
+type Source struct {
+	Kind     string `json:"kind"`               // "git", "local", "archive", "release" or a package registry: "npm", "pypi", "go"
+	URL      string `json:"url,omitempty"`      // Git remote, archive download or release repo
+	Path     string `json:"path,omitempty"`     // Local directory or archive
+	SHA256   string `json:"sha256,omitempty"`   // Expected archive checksum
+	Link     bool   `json:"link,omitempty"`     // Built in place at Path rather than copied
+	Package  string `json:"package,omitempty"`  // Package name in its registry
+	Version  string `json:"version,omitempty"`  // Requested version, range or release tag, empty for latest
+	Resolved string `json:"resolved,omitempty"` // Version installed, the module version for go, the tag for releases
+}
+
+// IsPackage reports whether the server is installed from a package registry
+// rather than built from a checkout
+func (s Source) IsPackage() bool {
+	return s.Kind == "npm" || s.Kind == "pypi" || s.Kind == "go"
+}
+
+// statePath is where sources are recorded, .mcp/state.json
+func statePath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cwd, ".mcp", "state.json"), nil
+}
+
+func loadState() (map[string]Source, error) {
+	state := make(map[string]Source)
+	path, err := statePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// LoadSource returns where the named server came from. Servers installed
+// before sources were recorded are git checkouts.
+func LoadSource(name string) (Source, error) {
+	state, err := loadState()
+	if err != nil {
+		return Source{}, err
+	}
+	if src, ok := state[name]; ok {
+		return src, nil
+	}
+	return Source{Kind: "git"}, nil
+}
+
+// SaveSource records where the named server came from
+func SaveSource(name string, src Source) error {
+	state, err := loadState()
+	if err != nil {
+		return err
+	}
+	state[name] = src
+
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -3,8 +3,6 @@ package injector
 import (
 	"fmt"
 	"os/exec"
-	"path/filepath"
-	"strings"
 
 	"mcpm/internal/builder"
 )
@@ -12,32 +10,17 @@ import (
 // McpServerDef is used for Gemini CLI (includes type field)
 type McpServerDef struct {
 	Type    string            `json:"type"`
-	Command string            `json:"command"`
-	Args    []string          `json:"args"`
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	HttpUrl string            `json:"httpUrl,omitempty"`
 	Env     map[string]string `json:"env,omitempty"`
 }
 
 func updateClaudeCode(cwd string, result *builder.BuildResult, env map[string]string, global bool) error {
-	// Extract server name from path
-	// Look for .mcp/servers/<name> pattern
-	name := "mcp-server"
-	if len(result.Args) > 0 {
-		path := result.Args[0]
-		// Find "servers" in path and get the next component
-		parts := strings.Split(path, string(filepath.Separator))
-		for i, part := range parts {
-			if part == "servers" && i+1 < len(parts) {
-				name = parts[i+1]
-				break
-			}
-		}
-	}
-	if name == "" || name == "." {
-		name = filepath.Base(result.Command)
-	}
+	name := serverName(result)
 
 	// Build command args for claude mcp add
-	// Format: claude mcp add [--scope SCOPE] [--env KEY=VALUE]... <name> <command> [args...]
+	// Format: claude mcp add [--scope SCOPE] [--env KEY=VALUE]... <name> -- <command> [args...]
 	cmdArgs := []string{"mcp", "add"}
 
 	// Add scope (user for global, local for project-specific)
@@ -52,11 +35,16 @@ func updateClaudeCode(cwd string, result *builder.BuildResult, env map[string]st
 		cmdArgs = append(cmdArgs, "--env", fmt.Sprintf("%s=%s", key, value))
 	}
 
-	// Add server name and command
-	cmdArgs = append(cmdArgs, name, result.Command)
+	if result.IsHTTP() {
+		// HTTP servers are registered by URL
+		cmdArgs = append(cmdArgs, "--transport", "http", name, result.URL)
+	} else {
+		// Add server name and command, after "--" so server flags aren't parsed by claude
+		cmdArgs = append(cmdArgs, name, "--", result.Command)
 
-	// Add server args
-	cmdArgs = append(cmdArgs, result.Args...)
+		// Add server args
+		cmdArgs = append(cmdArgs, result.RunArgs(env)...)
+	}
 
 	// Run claude mcp add command
 	cmd := exec.Command("claude", cmdArgs...)
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"mcpm/internal/builder"
 )
@@ -70,29 +69,21 @@ func updateGeminiCLI(cwd string, result *builder.BuildResult, env map[string]str
 		cfg.McpServers = make(map[string]McpServerDef)
 	}
 
-	// Extract server name from path
-	// Look for .mcp/servers/<name> pattern
-	name := "mcp-server"
-	if len(result.Args) > 0 {
-		path := result.Args[0]
-		// Find "servers" in path and get the next component
-		parts := strings.Split(path, string(filepath.Separator))
-		for i, part := range parts {
-			if part == "servers" && i+1 < len(parts) {
-				name = parts[i+1]
-				break
-			}
-		}
-	}
-	if name == "" || name == "." {
-		name = filepath.Base(result.Command)
-	}
+	name := serverName(result)
 
-	cfg.McpServers[name] = McpServerDef{
-		Type:    "stdio",
-		Command: result.Command,
-		Args:    result.Args,
-		Env:     env,
+	if result.IsHTTP() {
+		cfg.McpServers[name] = McpServerDef{
+			Type:    "http",
+			HttpUrl: result.URL,
+			Env:     env,
+		}
+	} else {
+		cfg.McpServers[name] = McpServerDef{
+			Type:    "stdio",
+			Command: result.Command,
+			Args:    result.RunArgs(env),
+			Env:     env,
+		}
 	}
 
 	data, err := cfg.MarshalJSON()
@@ -0,0 +1,88 @@
+package injector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"mcpm/internal/builder"
+	"mcpm/internal/fetcher"
+)
+
+// GeminiExtensionsDir is where Gemini CLI loads extensions from:
+// ~/.gemini/extensions, or .gemini/extensions in the project
+func GeminiExtensionsDir(cwd string, global bool) (string, error) {
+	if global {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not get home directory: %w", err)
+		}
+		return filepath.Join(home, ".gemini", "extensions"), nil
+	}
+	return filepath.Join(cwd, ".gemini", "extensions"), nil
+}
+
+// InstallGeminiExtension copies the extension at path into Gemini CLI's
+// extensions directory, replacing an earlier install, the way
+// "gemini extensions install" does. env holds the values entered for each
+// server, keyed by server name, and is written into that server's env.
+// Gemini CLI then loads the servers, context files and commands itself.
+func InstallGeminiExtension(path string, env map[string]map[string]string, global bool) error {
+	ext, err := builder.ReadGeminiExtension(path)
+	if err != nil {
+		return err
+	}
+	cwd, _ := os.Getwd()
+	dir, err := GeminiExtensionsDir(cwd, global)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(dir, ext.Name)
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+	if err := fetcher.CopyTree(path, target, map[string]bool{".git": true}); err != nil {
+		return fmt.Errorf("could not install extension %s: %w", ext.Name, err)
+	}
+
+	// Gemini CLI records where an extension came from for its own updates
+	meta, _ := json.MarshalIndent(map[string]string{"source": path, "type": "local"}, "", "  ")
+	if err := os.WriteFile(filepath.Join(target, ".gemini-extension-install.json"), meta, 0644); err != nil {
+		return err
+	}
+	if len(env) == 0 {
+		return nil
+	}
+
+	// Edit the copy generically, so fields mcpm doesn't know survive
+	configPath := filepath.Join(target, "gemini-extension.json")
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("invalid gemini-extension.json: %w", err)
+	}
+	servers, _ := cfg["mcpServers"].(map[string]interface{})
+	for serverName, s := range servers {
+		server, ok := s.(map[string]interface{})
+		if !ok || len(env[serverName]) == 0 {
+			continue
+		}
+		serverEnv, _ := server["env"].(map[string]interface{})
+		if serverEnv == nil {
+			serverEnv = make(map[string]interface{})
+		}
+		for name, value := range env[serverName] {
+			serverEnv[name] = value
+		}
+		server["env"] = serverEnv
+	}
+	data, err = json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(configPath, data, 0644)
+}
@@ -3,6 +3,8 @@ package injector
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"mcpm/internal/builder"
 )
@@ -31,3 +33,29 @@ func Register(result *builder.BuildResult, tools []TargetTool, env map[string]st
 	}
 	return nil
 }
+
+// serverName returns the name to register the server under
+func serverName(result *builder.BuildResult) string {
+	if result.Name != "" {
+		return result.Name
+	}
+
+	// Extract server name from path
+	// Look for .mcp/servers/<name> pattern
+	name := "mcp-server"
+	if len(result.Args) > 0 {
+		path := result.Args[0]
+		// Find "servers" in path and get the next component
+		parts := strings.Split(path, string(filepath.Separator))
+		for i, part := range parts {
+			if part == "servers" && i+1 < len(parts) {
+				name = parts[i+1]
+				break
+			}
+		}
+	}
+	if name == "" || name == "." {
+		name = filepath.Base(result.Command)
+	}
+	return name
+}
@@ -0,0 +1,294 @@
+// Package jsonschema implements the subset of JSON Schema mcpm needs:
+// validating manifests and describing config forms.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is a JSON Schema. Keywords not listed here are ignored.
+type Schema struct {
+	Ref         string             `json:"$ref"`
+	Defs        map[string]*Schema `json:"$defs"`
+	Definitions map[string]*Schema `json:"definitions"` // Pre-2019 name of $defs
+
+	Type        TypeList      `json:"type"`
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Default     interface{}   `json:"default"`
+	Enum        []interface{} `json:"enum"`
+	Const       interface{}   `json:"const"`
+
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *BoolOrSchema      `json:"additionalProperties"`
+	PropertyNames        *Schema            `json:"propertyNames"`
+
+	Items    *Schema `json:"items"`
+	MinItems *int    `json:"minItems"`
+
+	MinLength *int     `json:"minLength"`
+	Pattern   string   `json:"pattern"`
+	Minimum   *float64 `json:"minimum"`
+	Maximum   *float64 `json:"maximum"`
+
+	AnyOf []*Schema `json:"anyOf"`
+	OneOf []*Schema `json:"oneOf"`
+
+	root *Schema
+}
+
+// TypeList is the "type" keyword, which may be a string or a list
+type TypeList []string
+
+func (t *TypeList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = TypeList{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// BoolOrSchema is a keyword that is either true/false or a schema
+type BoolOrSchema struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+func (b *BoolOrSchema) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &b.Allowed); err == nil {
+		return nil
+	}
+	b.Allowed = true
+	return json.Unmarshal(data, &b.Schema)
+}
+
+// Parse decodes a schema document
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	s.setRoot(&s)
+	return &s, nil
+}
+
+// FromValue builds a schema from an already decoded document, e.g. one
+// embedded in a YAML file
+func FromValue(v interface{}) (*Schema, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Resolve follows $ref (local references only)
+func (s *Schema) Resolve() *Schema {
+	root := s.root
+	if root == nil {
+		root = s
+	}
+	for i := 0; s != nil && s.Ref != "" && i < 32; i++ {
+		s = root.lookup(s.Ref)
+	}
+	return s
+}
+
+func (s *Schema) lookup(ref string) *Schema {
+	var defs map[string]*Schema
+	switch {
+	case strings.HasPrefix(ref, "#/$defs/"):
+		defs, ref = s.Defs, strings.TrimPrefix(ref, "#/$defs/")
+	case strings.HasPrefix(ref, "#/definitions/"):
+		defs, ref = s.Definitions, strings.TrimPrefix(ref, "#/definitions/")
+	default:
+		return nil
+	}
+	return defs[ref]
+}
+
+// setRoot makes $refs inside s resolve against root
+func (s *Schema) setRoot(root *Schema) {
+	if s == nil || s.root != nil {
+		return
+	}
+	s.root = root
+	for _, defs := range []map[string]*Schema{s.Defs, s.Definitions, s.Properties} {
+		for _, sub := range defs {
+			sub.setRoot(root)
+		}
+	}
+	for _, sub := range append(append([]*Schema{s.Items, s.PropertyNames}, s.AnyOf...), s.OneOf...) {
+		sub.setRoot(root)
+	}
+	if s.AdditionalProperties != nil {
+		s.AdditionalProperties.Schema.setRoot(root)
+	}
+}
+
+// Validate checks v (as decoded by encoding/json) against the schema and
+// returns every violation, prefixed with the path to the offending value
+func (s *Schema) Validate(v interface{}) []error {
+	return s.validate("", v)
+}
+
+func (s *Schema) validate(path string, v interface{}) []error {
+	s = s.Resolve()
+	if s == nil {
+		return nil
+	}
+	fail := func(format string, args ...interface{}) []error {
+		msg := fmt.Sprintf(format, args...)
+		if path != "" {
+			msg = path + ": " + msg
+		}
+		return []error{fmt.Errorf("%s", msg)}
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(v) {
+		return fail("expected %s, got %s", strings.Join(s.Type, " or "), typeOf(v))
+	}
+	if s.Const != nil && !reflect.DeepEqual(s.Const, v) {
+		return fail("must be %v", s.Const)
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("must be one of %s", formatEnum(s.Enum))
+		}
+	}
+	if len(s.AnyOf) > 0 && countMatches(s.AnyOf, path, v) == 0 {
+		return fail("does not match any of the allowed forms")
+	}
+	if len(s.OneOf) > 0 && countMatches(s.OneOf, path, v) != 1 {
+		return fail("must match exactly one of the allowed forms")
+	}
+
+	var errs []error
+	switch val := v.(type) {
+	case string:
+		if s.MinLength != nil && len(val) < *s.MinLength {
+			errs = append(errs, fail("must be at least %d characters", *s.MinLength)...)
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(val) {
+				errs = append(errs, fail("%q does not match %s", val, s.Pattern)...)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			errs = append(errs, fail("must be >= %v", *s.Minimum)...)
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			errs = append(errs, fail("must be <= %v", *s.Maximum)...)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			errs = append(errs, fail("must have at least %d items", *s.MinItems)...)
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				errs = append(errs, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				errs = append(errs, fail("missing required property %q", name)...)
+			}
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			if s.PropertyNames != nil {
+				errs = append(errs, s.PropertyNames.validate(childPath, k)...)
+			}
+			if prop, ok := s.Properties[k]; ok {
+				errs = append(errs, prop.validate(childPath, val[k])...)
+			} else if ap := s.AdditionalProperties; ap != nil {
+				if !ap.Allowed {
+					errs = append(errs, fail("unknown property %q", k)...)
+				} else if ap.Schema != nil {
+					errs = append(errs, ap.Schema.validate(childPath, val[k])...)
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func countMatches(schemas []*Schema, path string, v interface{}) int {
+	n := 0
+	for _, sub := range schemas {
+		if len(sub.validate(path, v)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (t TypeList) matches(v interface{}) bool {
+	actual := typeOf(v)
+	for _, want := range t {
+		if want == actual || (want == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func formatEnum(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		data, _ := json.Marshal(v)
+		parts[i] = string(data)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,172 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+  "type": "object",
+  "properties": {
+    "name": { "type": "string", "minLength": 1, "pattern": "^[a-z]+$" },
+    "kind": { "type": "string", "enum": ["node", "go"] },
+    "port": { "type": "integer", "minimum": 1, "maximum": 65535 },
+    "steps": { "$ref": "#/$defs/steps" },
+    "env": { "type": "array", "items": { "$ref": "#/$defs/env" } },
+    "labels": {
+      "type": "object",
+      "propertyNames": { "pattern": "^[a-z]+$" },
+      "additionalProperties": { "type": "string" }
+    },
+    "legacy": { "$ref": "#/definitions/legacy" }
+  },
+  "required": ["name"],
+  "additionalProperties": false,
+  "$defs": {
+    "steps": { "type": "array", "minItems": 1, "items": { "type": "string" } },
+    "env": {
+      "type": "object",
+      "properties": { "name": { "type": "string" }, "secret": { "type": "boolean" } },
+      "required": ["name"],
+      "additionalProperties": false
+    },
+    "alias": { "$ref": "#/$defs/steps" }
+  },
+  "definitions": {
+    "legacy": { "type": ["string", "null"] }
+  }
+}`
+
+func validate(t *testing.T, doc string) []string {
+	t.Helper()
+	schema, err := Parse([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	var msgs []string
+	for _, err := range schema.Validate(v) {
+		msgs = append(msgs, err.Error())
+	}
+	return msgs
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string // the errors, in order
+	}{
+		{"valid", `{"name": "weather", "kind": "go", "port": 8080, "steps": ["make"], "env": [{"name": "KEY", "secret": true}], "labels": {"team": "sre"}, "legacy": null}`, nil},
+		{"wrong type at the root", `[]`, []string{"expected object, got array"}},
+		{"missing required property", `{}`, []string{`missing required property "name"`}},
+		{"unknown property", `{"name": "weather", "extra": 1}`, []string{`unknown property "extra"`}},
+		{"enum", `{"name": "weather", "kind": "rust"}`, []string{`kind: must be one of "node", "go"`}},
+		{"integer", `{"name": "weather", "port": 80.5}`, []string{"port: expected integer, got number"}},
+		{"minimum and maximum", `{"name": "weather", "port": 70000}`, []string{"port: must be <= 65535"}},
+		{"minLength and pattern", `{"name": ""}`, []string{"name: must be at least 1 characters", `name: "" does not match ^[a-z]+$`}},
+		{"$ref to $defs", `{"name": "weather", "steps": []}`, []string{"steps: must have at least 1 items"}},
+		{"$ref in items", `{"name": "weather", "env": [{"name": "A"}, {"secret": "yes"}]}`, []string{
+			`env[1]: missing required property "name"`,
+			"env[1].secret: expected boolean, got string",
+		}},
+		{"$ref to definitions", `{"name": "weather", "legacy": 1}`, []string{"legacy: expected string or null, got integer"}},
+		{"propertyNames and additionalProperties schema", `{"name": "weather", "labels": {"Team": "sre", "tier": 1}}`, []string{
+			`labels.Team: "Team" does not match ^[a-z]+$`,
+			"labels.tier: expected string, got integer",
+		}},
+		{"every error is reported", `{"kind": "rust", "port": 0}`, []string{
+			`missing required property "name"`,
+			`kind: must be one of "node", "go"`,
+			"port: must be >= 1",
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := validate(t, tc.doc)
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	schema, err := Parse([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A $ref to a $ref is followed to the end
+	alias := schema.Defs["alias"].Resolve()
+	if alias != schema.Defs["steps"] {
+		t.Errorf("alias resolved to %+v, want the steps schema", alias)
+	}
+	// Nested schemas resolve against the document they came from
+	if item := schema.Properties["env"].Items.Resolve(); item != schema.Defs["env"] {
+		t.Errorf("env items resolved to %+v, want the env schema", item)
+	}
+	// Only local references are supported
+	remote := &Schema{Ref: "https://example.com/schema.json"}
+	if got := remote.Resolve(); got != nil {
+		t.Errorf("remote $ref resolved to %+v, want nil", got)
+	}
+}
+
+func TestRefCycle(t *testing.T) {
+	schema, err := Parse([]byte(`{"$ref": "#/$defs/a", "$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A cycle gives up instead of looping
+	schema.Validate("anything")
+}
+
+func TestAnyOfOneOf(t *testing.T) {
+	schema, err := Parse([]byte(`{
+	  "properties": {
+	    "any": { "anyOf": [{ "type": "string" }, { "type": "integer" }] },
+	    "one": { "oneOf": [{ "type": "number" }, { "type": "integer" }] }
+	  }
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{`{"any": "x", "one": 1.5}`, ""},
+		{`{"any": true}`, "any: does not match any of the allowed forms"},
+		{`{"one": 2}`, "one: must match exactly one of the allowed forms"}, // 2 is a number and an integer
+	}
+	for _, tc := range tests {
+		var v interface{}
+		if err := json.Unmarshal([]byte(tc.doc), &v); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, err := range schema.Validate(v) {
+			got = append(got, err.Error())
+		}
+		if strings.Join(got, "\n") != tc.want {
+			t.Errorf("%s: errors %q, want %q", tc.doc, got, tc.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, doc := range []string{
+		`not json`,
+		`{"type": 1}`,
+		`{"properties": []}`,
+		`{"additionalProperties": "yes"}`,
+	} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("Parse(%s) succeeded, want an error", doc)
+		}
+	}
+}
@@ -1,31 +1,81 @@
 package tui
 
 import (
+	"context"
+	"path/filepath"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"mcpm/internal/builder"
 	"mcpm/internal/fetcher"
 )
 
-type msgRepoFetched struct{ path string }
+type msgRepoFetched struct {
+	path     string
+	resolved string // release tag, for release sources
+}
 type msgBuilt struct{ result *builder.BuildResult }
+type msgPrepared struct{}
 type msgError struct{ err error }
 
-func fetchRepoCmd(url string) tea.Cmd {
+// fetchSourceCmd clones a git source, copies a local one (linked ones are
+// used in place), extracts an archive, downloads a release binary, or creates the directory a package
+// is installed into
+func fetchSourceCmd(ctx context.Context, src fetcher.Source) tea.Cmd {
 	return func() tea.Msg {
-		path, err := fetcher.Clone(url)
+		var path, resolved string
+		var err error
+		switch {
+		case src.IsPackage():
+			path, err = fetcher.PackageDir(src)
+		case src.Kind == "local" && src.Link:
+			path = src.Path
+		case src.Kind == "local":
+			path, err = fetcher.CopyLocal(src)
+		case src.Kind == "archive":
+			path, err = fetcher.FetchArchive(ctx, src)
+		case src.Kind == "release":
+			path, resolved, err = fetcher.FetchRelease(ctx, src)
+		default:
+			path, err = fetcher.Clone(ctx, src.URL)
+		}
 		if err != nil {
 			return msgError{err}
 		}
-		return msgRepoFetched{path}
+		return msgRepoFetched{path, resolved}
 	}
 }
 
-func buildRepoCmd(path string) tea.Cmd {
+// buildSourceCmd builds the server in path, or installs it from its package
+// registry, and records the source for update
+func buildSourceCmd(ctx context.Context, path string, src fetcher.Source, opts builder.Options) tea.Cmd {
 	return func() tea.Msg {
-		res, err := builder.DetectAndBuild(path)
+		var res *builder.BuildResult
+		var err error
+		if src.IsPackage() {
+			res, src.Resolved, err = builder.InstallPackage(ctx, path, src.Kind, src.Package, src.Version)
+		} else {
+			if src.Kind == "release" {
+				// Release assets are already built
+				opts.Builder = "prebuilt"
+			}
+			res, err = builder.DetectAndBuild(ctx, path, opts)
+		}
 		if err != nil {
 			return msgError{err}
 		}
+		if err := fetcher.SaveSource(filepath.Base(path), src); err != nil {
+			return msgError{err}
+		}
 		return msgBuilt{res}
 	}
 }
+
+// prepareServersCmd builds the projects the selected servers need
+func prepareServersCmd(ctx context.Context, servers []*builder.BuildResult) tea.Cmd {
+	return func() tea.Msg {
+		if err := builder.PrepareServers(ctx, servers); err != nil {
+			return msgError{err}
+		}
+		return msgPrepared{}
+	}
+}
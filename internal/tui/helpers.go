@@ -1,85 +1,249 @@
 package tui
 
 import (
-	tea "github.com/charmbracelet/bubbletea"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
 	"mcpm/internal/builder"
 	"mcpm/internal/injector"
 )
 
-func updateEnvInputs(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "enter":
-		if m.focusIndex == len(m.inputs)-1 {
-			m.state = stateSelectingClient
-			return m, nil
+// newEnvInputs creates one text input per environment variable or setting
+func newEnvInputs(fields []builder.EnvVar) []textinput.Model {
+	inputs := make([]textinput.Model, len(fields))
+	for i, field := range fields {
+		t := textinput.New()
+		t.Placeholder = field.Name
+		if field.Description != "" {
+			t.Placeholder = field.Description
+		}
+		if field.Type == "boolean" {
+			t.Placeholder += " (true/false)"
+		}
+		t.Prompt = fmt.Sprintf("%s: ", field.Name)
+		if field.Suggested {
+			t.Prompt = fmt.Sprintf("%s (suggested): ", field.Name)
+		} else if field.Optional {
+			t.Prompt = fmt.Sprintf("%s (optional): ", field.Name)
+		}
+		if field.Secret {
+			t.EchoMode = textinput.EchoPassword
+			t.EchoCharacter = '•'
 		}
-		m.inputs[m.focusIndex].Blur()
-		m.focusIndex++
-		m.inputs[m.focusIndex].Focus()
-		return m, nil
+		t.SetValue(field.Default)
+		if i == 0 {
+			t.Focus()
+		}
+		inputs[i] = t
+	}
+	return inputs
+}
 
-	case "tab":
-		m.inputs[m.focusIndex].Blur()
-		m.focusIndex = (m.focusIndex + 1) % len(m.inputs)
-		m.inputs[m.focusIndex].Focus()
-		return m, nil
+// envInputError reports why input i can't be accepted yet, if it can't
+func envInputError(fields []builder.EnvVar, inputs []textinput.Model, i int) error {
+	if i >= len(fields) {
+		return nil
 	}
+	return fields[i].Check(inputs[i].Value())
+}
 
-	var cmd tea.Cmd
-	m.inputs[m.focusIndex], cmd = m.inputs[m.focusIndex].Update(msg)
-	return m, cmd
+// firstEnvInputError returns the first input that can't be accepted, and why
+func firstEnvInputError(fields []builder.EnvVar, inputs []textinput.Model) (int, error) {
+	for i := range inputs {
+		if err := envInputError(fields, inputs, i); err != nil {
+			return i, err
+		}
+	}
+	return 0, nil
 }
 
-func updateClientSelection(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "up", "k":
-		if m.cursor > 0 {
-			m.cursor--
-		}
-	case "down", "j":
-		if m.cursor < len(m.clients)-1 {
-			m.cursor++
-		}
-	case " ":
-		m.selected[m.cursor] = !m.selected[m.cursor]
-	case "enter":
-		m.state = stateDone
-
-		// Gather Env
-		finalEnv := make(map[string]string)
-		if m.buildResult != nil {
-			for i, need := range m.buildResult.EnvNeeds {
-				finalEnv[need] = m.inputs[i].Value()
-			}
+// formValues maps each field's name to the value entered for it
+func formValues(fields []builder.EnvVar, inputs []textinput.Model) map[string]string {
+	values := make(map[string]string)
+	for i, field := range fields {
+		if i >= len(inputs) {
+			break
+		}
+		values[field.Name] = strings.TrimSpace(inputs[i].Value())
+	}
+	return values
+}
+
+// collectEnv maps the entered values to the result's variables, leaving out
+// optional variables that were left empty. Entered settings are applied to
+// the result, and its fixed env is included.
+func collectEnv(result *builder.BuildResult, values map[string]string) map[string]string {
+	env := make(map[string]string)
+	for _, field := range result.EnvNeeds {
+		value := values[field.Name]
+		if value == "" && field.Optional {
+			continue
+		}
+		env[field.Name] = value
+	}
+
+	config := make(map[string]string)
+	for _, field := range result.Config {
+		config[field.Name] = values[field.Name]
+	}
+	result.ApplyConfig(config)
+	for name, value := range result.Env {
+		if _, ok := env[name]; !ok {
+			env[name] = value
+		}
+	}
+	return env
+}
+
+// renderEnvForm shows the env and config inputs
+func renderEnvForm(inputs []textinput.Model, inputErr error) string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Configuration Required"))
+	b.WriteString("\n\n")
+	for i := range inputs {
+		b.WriteString(inputs[i].View())
+		b.WriteString("\n")
+	}
+	if inputErr != nil {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render(inputErr.Error()))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n(Press Enter to confirm, required values can't be empty; leave suggested ones empty to skip)")
+	return b.String()
+}
+
+// renderDone is the final message, with how to start HTTP servers
+func renderDone(message string, result *builder.BuildResult) string {
+	out := successStyle.Render(message)
+	if result != nil && result.IsHTTP() {
+		start := strings.Join(append([]string{result.Command}, result.Args...), " ")
+		out += fmt.Sprintf("\n\nRegistered %s. Start the server with:\n  %s", result.URL, start)
+	}
+	if result != nil {
+		for _, err := range result.BuildErrors {
+			out += fmt.Sprintf("\nNote: %v", err)
 		}
+	}
+	return out
+}
+
+// renderEntrySelection lists the entry point candidates with a cursor
+func renderEntrySelection(candidates []builder.Candidate, cursor int) string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Select Entry Point"))
+	b.WriteString("\n")
+	for i, c := range candidates {
+		line := fmt.Sprintf("  %s", c.Label)
+		if cursor == i {
+			line = focusedStyle.Render(fmt.Sprintf("> %s", c.Label))
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n(Enter to select)")
+	return b.String()
+}
 
-		// Map selection
-		var tools []injector.TargetTool
-		if m.selected[0] {
-			tools = append(tools, injector.TargetClaudeCode)
+// renderServerSelection lists the servers found in the repo with checkboxes
+func renderServerSelection(servers []*builder.BuildResult, selected map[int]bool, cursor int, inputErr error) string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Select Servers"))
+	b.WriteString("\n")
+	for i, server := range servers {
+		checked := "[ ]"
+		if selected[i] {
+			checked = "[x]"
 		}
-		if m.selected[1] {
-			tools = append(tools, injector.TargetGeminiCLI)
+		label := server.Name
+		if server.IsHTTP() {
+			label += " (" + server.URL + ")"
 		}
+		line := fmt.Sprintf("  %s %s", checked, label)
+		if cursor == i {
+			line = focusedStyle.Render(fmt.Sprintf("> %s %s", checked, label))
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if inputErr != nil {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render(inputErr.Error()))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n(Space to toggle, Enter to continue)")
+	return b.String()
+}
+
+// selectAll marks every server selected
+func selectAll(servers []*builder.BuildResult) map[int]bool {
+	selected := make(map[int]bool)
+	for i := range servers {
+		selected[i] = true
+	}
+	return selected
+}
 
-		err := injector.Register(m.buildResult, tools, finalEnv, m.global)
-		if err != nil {
-			m.err = err
-			return m, tea.Quit
+// keepSelected drops the servers that weren't selected
+func keepSelected(result *builder.BuildResult, selected map[int]bool) error {
+	var kept []*builder.BuildResult
+	for i, server := range result.Servers {
+		if selected[i] {
+			kept = append(kept, server)
 		}
-		return m, tea.Quit
 	}
-	return m, nil
+	if len(kept) == 0 {
+		return fmt.Errorf("select at least one server")
+	}
+	result.Servers = kept
+	return nil
 }
 
-// registerClients is a helper to register with selected clients
-func registerClients(result *builder.BuildResult, selected map[int]bool, env map[string]string, global bool) error {
+// needsPrepare reports whether a selected server has a project to build
+func needsPrepare(result *builder.BuildResult) bool {
+	for _, server := range result.Servers {
+		if server.BuildDir != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// registerClients registers the result, or each of its servers, with the
+// selected clients. A Gemini CLI extension is installed as one instead.
+func registerClients(result *builder.BuildResult, selected map[int]bool, values map[string]string, global bool) error {
 	var tools []injector.TargetTool
 	if selected[0] {
 		tools = append(tools, injector.TargetClaudeCode)
 	}
-	if selected[1] {
+	if selected[1] && result.GeminiExtension == "" {
 		tools = append(tools, injector.TargetGeminiCLI)
 	}
-	return injector.Register(result, tools, env, global)
+	if len(result.Servers) == 0 && result.GeminiExtension == "" {
+		return injector.Register(result, tools, collectEnv(result, values), global)
+	}
+
+	if selected[1] && result.GeminiExtension != "" {
+		// Gemini CLI loads the extension's servers itself, each with the
+		// values it asked for
+		env := make(map[string]map[string]string)
+		for _, server := range result.Servers {
+			env[server.Name] = make(map[string]string)
+			for _, field := range server.EnvNeeds {
+				if value := values[field.Name]; value != "" {
+					env[server.Name][field.Name] = value
+				}
+			}
+		}
+		if err := injector.InstallGeminiExtension(result.GeminiExtension, env, global); err != nil {
+			return fmt.Errorf("gemini extension install failed: %w", err)
+		}
+	}
+	for _, server := range result.Servers {
+		if err := injector.Register(server, tools, collectEnv(server, values), global); err != nil {
+			return err
+		}
+	}
+	return nil
 }
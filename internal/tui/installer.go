@@ -1,13 +1,13 @@
 package tui
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"mcpm/internal/builder"
+	"mcpm/internal/fetcher"
 )
 
 type sessionState int
@@ -15,97 +15,99 @@ type sessionState int
 const (
 	stateFetching sessionState = iota
 	stateBuilding
+	stateSelectingEntry
+	stateSelectingServers
 	stateConfigEnv
 	stateSelectingClient
 	stateDone
 )
 
 type Model struct {
-	state       sessionState
-	err         error
-	repoUrl     string
-	repoName    string // User input name
-	repoPath    string
-	buildResult *builder.BuildResult
-	global      bool
-
-	spinner    spinner.Model
-	inputs     []textinput.Model
-	focusIndex int
-
-	clients  []string
-	selected map[int]bool
-	cursor   int
+	session
+
+	source    fetcher.Source
+	repoName  string // User input name
+	repoPath  string
+	buildOpts builder.Options
+
+	spinner spinner.Model
 }
 
-func NewInstallModel(repoUrl, repoName string, global bool) Model {
+func NewInstallModel(ctx context.Context, src fetcher.Source, repoName string, global bool, opts builder.Options) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = focusedStyle
 
-	scope := "Current Dir"
-	if global {
-		scope = "Global"
-	}
-
-	return Model{
-		state:    stateFetching,
-		repoUrl:  repoUrl,
-		repoName: repoName,
-		global:   global,
-		spinner:  s,
-		clients:  []string{fmt.Sprintf("Claude Code (%s)", scope), fmt.Sprintf("Gemini CLI (%s)", scope)},
-		selected: map[int]bool{0: true, 1: true},
+	m := Model{
+		session:   newSession(ctx, stateFetching, global),
+		source:    src,
+		repoName:  repoName,
+		buildOpts: opts,
+		spinner:   s,
 	}
+	m.clientTitle = "Select Target Clients"
+	m.clientHint = "(Space to toggle, Enter to install)"
+	return m
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(m.spinner.Tick, fetchRepoCmd(m.repoUrl))
+	return tea.Batch(m.spinner.Tick, fetchSourceCmd(m.ctx, m.source))
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		if msg.String() == "ctrl+c" || msg.String() == "esc" {
+			// A second press gives up waiting, for steps that don't stop
+			if m.cancelling {
+				return m, tea.Quit
+			}
+			m.cancel()
+			// Wait for the running step to be killed along with its
+			// process group, which would otherwise outlive mcpm
+			if m.state == stateFetching || m.state == stateBuilding {
+				m.cancelling = true
+				return m, nil
+			}
 			return m, tea.Quit
 		}
-		if m.state == stateConfigEnv {
-			return updateEnvInputs(m, msg)
-		}
-		if m.state == stateSelectingClient {
-			return updateClientSelection(m, msg)
-		}
+		m.session, cmd = m.updateKey(msg)
+		return m, cmd
 
 	case msgRepoFetched:
+		if m.cancelling {
+			return m, tea.Quit
+		}
 		m.repoPath = msg.path
+		if msg.resolved != "" {
+			m.source.Resolved = msg.resolved
+		}
 		m.state = stateBuilding
-		return m, buildRepoCmd(m.repoPath)
+		return m, buildSourceCmd(m.ctx, m.repoPath, m.source, m.buildOpts)
 
 	case msgBuilt:
-		m.buildResult = msg.result
-		if len(m.buildResult.EnvNeeds) > 0 {
-			m.state = stateConfigEnv
-			m.inputs = make([]textinput.Model, len(m.buildResult.EnvNeeds))
-			for i, envName := range m.buildResult.EnvNeeds {
-				t := textinput.New()
-				t.Placeholder = envName
-				t.Prompt = fmt.Sprintf("%s: ", envName)
-				if i == 0 {
-					t.Focus()
-				}
-				m.inputs[i] = t
-			}
-			return m, nil
+		if m.cancelling {
+			return m, tea.Quit
 		}
-		m.state = stateSelectingClient
-		return m, nil
+		m.session, cmd = m.built(msg.result)
+		return m, cmd
+
+	case msgPrepared:
+		if m.cancelling {
+			return m, tea.Quit
+		}
+		m.session, cmd = m.afterBuild()
+		return m, cmd
 
 	case msgError:
 		m.err = msg.err
+		if m.cancelling {
+			m.err = fmt.Errorf("installation cancelled")
+		}
 		return m, tea.Quit
 
 	case spinner.TickMsg:
-		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 	}
@@ -118,46 +120,17 @@ func (m Model) View() string {
 		return errorStyle.Render(fmt.Sprintf("L Error: %v\n", m.err))
 	}
 
+	if m.cancelling {
+		return fmt.Sprintf("%s Cancelling, waiting for the running step to stop... (ctrl+c again to quit now)", m.spinner.View())
+	}
+
 	switch m.state {
 	case stateFetching:
 		return fmt.Sprintf("%s Fetching %s...", m.spinner.View(), m.repoName)
 	case stateBuilding:
 		return fmt.Sprintf("%s Analyzing and building project...", m.spinner.View())
-	case stateConfigEnv:
-		var b strings.Builder
-		b.WriteString(titleStyle.Render("Configuration Required"))
-		b.WriteString("\n\n")
-		for i := range m.inputs {
-			b.WriteString(m.inputs[i].View())
-			b.WriteString("\n")
-		}
-		b.WriteString("\n(Press Enter to confirm)")
-		return b.String()
-	case stateSelectingClient:
-		var b strings.Builder
-		b.WriteString(titleStyle.Render("Select Target Clients"))
-		b.WriteString("\n")
-		for i, choice := range m.clients {
-			cursor := " "
-			if m.cursor == i {
-				cursor = ">"
-			}
-			checked := "[ ]"
-			if m.selected[i] {
-				checked = "[x]"
-			}
-			line := fmt.Sprintf("%s %s %s", cursor, checked, choice)
-			if m.cursor == i {
-				b.WriteString(focusedStyle.Render(line))
-			} else {
-				b.WriteString(line)
-			}
-			b.WriteString("\n")
-		}
-		b.WriteString("\n(Space to toggle, Enter to install)")
-		return b.String()
 	case stateDone:
-		return successStyle.Render("( Successfully installed and configured!")
+		return renderDone("( Successfully installed and configured!", m.buildResult)
 	}
-	return ""
+	return m.session.view()
 }
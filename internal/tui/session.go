@@ -0,0 +1,243 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"mcpm/internal/builder"
+)
+
+// session holds what the install and update screens share once a build is
+// done: the server checklist, the entry point, the env form and the client
+// choice. Both models embed it.
+type session struct {
+	state       sessionState
+	err         error
+	buildResult *builder.BuildResult
+	global      bool
+
+	// ctx is cancelled on ctrl+c to kill the running fetch or build
+	ctx        context.Context
+	cancel     context.CancelFunc
+	cancelling bool
+
+	entryCursor int
+
+	// Checklist of the servers in a repo that ships several
+	serverCursor   int
+	serverSelected map[int]bool
+
+	inputs     []textinput.Model
+	focusIndex int
+	inputErr   error // Why the last input or selection was not accepted
+
+	clients     []string
+	selected    map[int]bool
+	cursor      int
+	clientTitle string // Heading of the client choice
+	clientHint  string // What Enter does there
+}
+
+func newSession(ctx context.Context, state sessionState, global bool) session {
+	scope := "Current Dir"
+	if global {
+		scope = "Global"
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return session{
+		state:    state,
+		global:   global,
+		ctx:      ctx,
+		cancel:   cancel,
+		clients:  []string{fmt.Sprintf("Claude Code (%s)", scope), fmt.Sprintf("Gemini CLI (%s)", scope)},
+		selected: map[int]bool{0: true, 1: true},
+	}
+}
+
+// built moves on from a finished build: to the server checklist, the entry
+// point choice, or straight to the rest
+func (s session) built(result *builder.BuildResult) (session, tea.Cmd) {
+	s.buildResult = result
+	if len(result.Servers) > 1 {
+		s.state = stateSelectingServers
+		s.serverSelected = selectAll(result.Servers)
+		return s, nil
+	}
+	if len(result.Candidates) > 1 {
+		s.state = stateSelectingEntry
+		return s, nil
+	}
+	return s.afterServers()
+}
+
+// afterServers builds what the selected servers need, then moves on
+func (s session) afterServers() (session, tea.Cmd) {
+	if needsPrepare(s.buildResult) {
+		s.state = stateBuilding
+		return s, prepareServersCmd(s.ctx, s.buildResult.Servers)
+	}
+	return s.afterBuild()
+}
+
+// afterBuild moves to the next step once the entry point is known
+func (s session) afterBuild() (session, tea.Cmd) {
+	if fields := s.buildResult.Fields(); len(fields) > 0 {
+		s.state = stateConfigEnv
+		s.inputs = newEnvInputs(fields)
+		s.focusIndex = 0
+		return s, nil
+	}
+	s.state = stateSelectingClient
+	return s, nil
+}
+
+// updateKey handles a key in the step the session is at
+func (s session) updateKey(msg tea.KeyMsg) (session, tea.Cmd) {
+	switch s.state {
+	case stateSelectingEntry:
+		return s.updateEntrySelection(msg)
+	case stateSelectingServers:
+		return s.updateServerSelection(msg)
+	case stateConfigEnv:
+		return s.updateEnvInputs(msg)
+	case stateSelectingClient:
+		return s.updateClientSelection(msg)
+	}
+	return s, nil
+}
+
+func (s session) updateServerSelection(msg tea.KeyMsg) (session, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if s.serverCursor > 0 {
+			s.serverCursor--
+		}
+	case "down", "j":
+		if s.serverCursor < len(s.buildResult.Servers)-1 {
+			s.serverCursor++
+		}
+	case " ":
+		s.serverSelected[s.serverCursor] = !s.serverSelected[s.serverCursor]
+	case "enter":
+		if s.inputErr = keepSelected(s.buildResult, s.serverSelected); s.inputErr != nil {
+			return s, nil
+		}
+		return s.afterServers()
+	}
+	return s, nil
+}
+
+func (s session) updateEntrySelection(msg tea.KeyMsg) (session, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if s.entryCursor > 0 {
+			s.entryCursor--
+		}
+	case "down", "j":
+		if s.entryCursor < len(s.buildResult.Candidates)-1 {
+			s.entryCursor++
+		}
+	case "enter":
+		s.buildResult.Use(s.buildResult.Candidates[s.entryCursor])
+		return s.afterBuild()
+	}
+	return s, nil
+}
+
+func (s session) updateEnvInputs(msg tea.KeyMsg) (session, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		fields := s.buildResult.Fields()
+		if s.inputErr = envInputError(fields, s.inputs, s.focusIndex); s.inputErr != nil {
+			return s, nil
+		}
+		if s.focusIndex < len(s.inputs)-1 {
+			return s.focus(s.focusIndex + 1), nil
+		}
+		// Fields skipped with tab are checked on submit too
+		if i, err := firstEnvInputError(fields, s.inputs); err != nil {
+			s = s.focus(i)
+			s.inputErr = err
+			return s, nil
+		}
+		s.state = stateSelectingClient
+		return s, nil
+
+	case "tab":
+		return s.focus((s.focusIndex + 1) % len(s.inputs)), nil
+	}
+
+	var cmd tea.Cmd
+	s.inputs[s.focusIndex], cmd = s.inputs[s.focusIndex].Update(msg)
+	return s, cmd
+}
+
+// focus moves the env form's focus to input i
+func (s session) focus(i int) session {
+	s.inputs[s.focusIndex].Blur()
+	s.focusIndex = i
+	s.inputs[s.focusIndex].Focus()
+	return s
+}
+
+func (s session) updateClientSelection(msg tea.KeyMsg) (session, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case "down", "j":
+		if s.cursor < len(s.clients)-1 {
+			s.cursor++
+		}
+	case " ":
+		s.selected[s.cursor] = !s.selected[s.cursor]
+	case "enter":
+		s.state = stateDone
+		if s.selected[0] || s.selected[1] {
+			values := formValues(s.buildResult.Fields(), s.inputs)
+			s.err = registerClients(s.buildResult, s.selected, values, s.global)
+		}
+		return s, tea.Quit
+	}
+	return s, nil
+}
+
+// view renders the step the session is at, or "" when the model shows it
+func (s session) view() string {
+	switch s.state {
+	case stateSelectingEntry:
+		return renderEntrySelection(s.buildResult.Candidates, s.entryCursor)
+	case stateSelectingServers:
+		return renderServerSelection(s.buildResult.Servers, s.serverSelected, s.serverCursor, s.inputErr)
+	case stateConfigEnv:
+		return renderEnvForm(s.inputs, s.inputErr)
+	case stateSelectingClient:
+		var b strings.Builder
+		b.WriteString(titleStyle.Render(s.clientTitle))
+		b.WriteString("\n")
+		for i, choice := range s.clients {
+			cursor := " "
+			if s.cursor == i {
+				cursor = ">"
+			}
+			checked := "[ ]"
+			if s.selected[i] {
+				checked = "[x]"
+			}
+			line := fmt.Sprintf("%s %s %s", cursor, checked, choice)
+			if s.cursor == i {
+				b.WriteString(focusedStyle.Render(line))
+			} else {
+				b.WriteString(line)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n" + s.clientHint)
+		return b.String()
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"mcpm/internal/builder"
+	"mcpm/internal/fetcher"
+)
+
+func press(t *testing.T, s session, keys ...tea.KeyMsg) session {
+	t.Helper()
+	for _, key := range keys {
+		s, _ = s.updateKey(key)
+	}
+	return s
+}
+
+var (
+	enter = tea.KeyMsg{Type: tea.KeyEnter}
+	tab   = tea.KeyMsg{Type: tea.KeyTab}
+	space = tea.KeyMsg{Type: tea.KeySpace}
+	down  = tea.KeyMsg{Type: tea.KeyDown}
+)
+
+func typed(text string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)}
+}
+
+func TestEnvFormValidatesEveryFieldOnSubmit(t *testing.T) {
+	result := &builder.BuildResult{Name: "weather", Command: "weather", EnvNeeds: []builder.EnvVar{
+		{Name: "API_KEY"},
+		{Name: "REGION", Optional: true},
+		{Name: "ENDPOINT"},
+	}}
+	s, _ := newSession(context.Background(), stateBuilding, false).built(result)
+	if s.state != stateConfigEnv {
+		t.Fatalf("state = %v, want the env form", s.state)
+	}
+
+	// Skip API_KEY with tab, then submit from the last field
+	s = press(t, s, tab, tab, typed("https://example.com"), enter)
+	if s.state != stateConfigEnv {
+		t.Fatal("submitted with API_KEY empty")
+	}
+	if s.focusIndex != 0 || s.inputErr == nil {
+		t.Errorf("focus = %d, error = %v, want API_KEY focused with its error", s.focusIndex, s.inputErr)
+	}
+
+	s = press(t, s, typed("secret"), enter, enter, enter)
+	if s.state != stateSelectingClient {
+		t.Fatalf("state = %v after filling the form, error %v", s.state, s.inputErr)
+	}
+	values := formValues(result.Fields(), s.inputs)
+	if values["API_KEY"] != "secret" || values["ENDPOINT"] != "https://example.com" {
+		t.Errorf("values = %v", values)
+	}
+}
+
+func TestServerChecklist(t *testing.T) {
+	result := &builder.BuildResult{Servers: []*builder.BuildResult{
+		{Name: "api", Command: "api"},
+		{Name: "admin", Command: "admin", EnvNeeds: []builder.EnvVar{{Name: "ADMIN_TOKEN"}}},
+	}}
+	s, _ := newSession(context.Background(), stateBuilding, false).built(result)
+	if s.state != stateSelectingServers {
+		t.Fatalf("state = %v, want the server checklist", s.state)
+	}
+
+	// Nothing selected is refused
+	s = press(t, s, space, down, space, enter)
+	if s.state != stateSelectingServers || s.inputErr == nil {
+		t.Fatalf("state = %v, error = %v, want the checklist to refuse no servers", s.state, s.inputErr)
+	}
+
+	s = press(t, s, space, enter)
+	if len(result.Servers) != 1 || result.Servers[0].Name != "admin" {
+		t.Fatalf("servers = %v, want only admin", result.Servers)
+	}
+	if s.state != stateConfigEnv || len(s.inputs) != 1 {
+		t.Errorf("state = %v with %d inputs, want the form for admin's env", s.state, len(s.inputs))
+	}
+}
+
+func TestSecondCtrlCQuitsWithoutWaiting(t *testing.T) {
+	ctrlC := tea.KeyMsg{Type: tea.KeyCtrlC}
+	m := NewInstallModel(context.Background(), fetcher.Source{Kind: "git"}, "weather", false, builder.Options{})
+
+	next, cmd := m.Update(ctrlC)
+	if cmd != nil || !next.(Model).cancelling {
+		t.Fatal("first ctrl+c while fetching should wait for the step to stop")
+	}
+	if next.(Model).ctx.Err() == nil {
+		t.Error("first ctrl+c didn't cancel the fetch")
+	}
+	_, cmd = next.Update(ctrlC)
+	if cmd == nil {
+		t.Fatal("second ctrl+c kept waiting")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("second ctrl+c didn't quit")
+	}
+}
@@ -1,104 +1,93 @@
 package tui
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"mcpm/internal/builder"
+	"mcpm/internal/fetcher"
 )
 
-type updateState int
+// UpdateModel rebuilds a server that was already fetched and re-registers
+// it, going through the same steps as an install
+type UpdateModel struct {
+	session
 
-const (
-	updateStateBuilding updateState = iota
-	updateStateConfigEnv
-	updateStateSelectingClient
-	updateStateDone
-)
+	serverPath string
+	serverName string
+	source     fetcher.Source
+	buildOpts  builder.Options
 
-type UpdateModel struct {
-	state       updateState
-	err         error
-	serverPath  string
-	serverName  string
-	buildResult *builder.BuildResult
-	global      bool
-
-	spinner    spinner.Model
-	inputs     []textinput.Model
-	focusIndex int
-
-	clients  []string
-	selected map[int]bool
-	cursor   int
+	spinner spinner.Model
 }
 
-func NewUpdateModel(serverPath, serverName string, global bool) UpdateModel {
+func NewUpdateModel(ctx context.Context, serverPath, serverName string, src fetcher.Source, global bool, opts builder.Options) UpdateModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = focusedStyle
 
-	scope := "Current Dir"
-	if global {
-		scope = "Global"
-	}
-
-	return UpdateModel{
-		state:      updateStateBuilding,
+	m := UpdateModel{
+		session:    newSession(ctx, stateBuilding, global),
 		serverPath: serverPath,
 		serverName: serverName,
-		global:     global,
+		source:     src,
+		buildOpts:  opts,
 		spinner:    s,
-		clients:    []string{fmt.Sprintf("Claude Code (%s)", scope), fmt.Sprintf("Gemini CLI (%s)", scope)},
-		selected:   map[int]bool{0: true, 1: true},
 	}
+	m.clientTitle = "Re-register with Clients?"
+	m.clientHint = "(Space to toggle, Enter to update)"
+	return m
 }
 
 func (m UpdateModel) Init() tea.Cmd {
-	return tea.Batch(m.spinner.Tick, buildRepoCmd(m.serverPath))
+	return tea.Batch(m.spinner.Tick, buildSourceCmd(m.ctx, m.serverPath, m.source, m.buildOpts))
 }
 
 func (m UpdateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		if msg.String() == "ctrl+c" || msg.String() == "esc" {
+			// A second press gives up waiting, for steps that don't stop
+			if m.cancelling {
+				return m, tea.Quit
+			}
+			m.cancel()
+			// Wait for the running build to be killed along with its
+			// process group, which would otherwise outlive mcpm
+			if m.state == stateBuilding {
+				m.cancelling = true
+				return m, nil
+			}
 			return m, tea.Quit
 		}
-		if m.state == updateStateConfigEnv {
-			return m.updateEnvInputs(msg)
-		}
-		if m.state == updateStateSelectingClient {
-			return m.updateClientSelection(msg)
-		}
+		m.session, cmd = m.updateKey(msg)
+		return m, cmd
 
 	case msgBuilt:
-		m.buildResult = msg.result
-		if len(m.buildResult.EnvNeeds) > 0 {
-			m.state = updateStateConfigEnv
-			m.inputs = make([]textinput.Model, len(m.buildResult.EnvNeeds))
-			for i, envName := range m.buildResult.EnvNeeds {
-				t := textinput.New()
-				t.Placeholder = envName
-				t.Prompt = fmt.Sprintf("%s: ", envName)
-				if i == 0 {
-					t.Focus()
-				}
-				m.inputs[i] = t
-			}
-			return m, nil
+		if m.cancelling {
+			return m, tea.Quit
 		}
-		m.state = updateStateSelectingClient
-		return m, nil
+		m.session, cmd = m.built(msg.result)
+		return m, cmd
+
+	case msgPrepared:
+		if m.cancelling {
+			return m, tea.Quit
+		}
+		m.session, cmd = m.afterBuild()
+		return m, cmd
 
 	case msgError:
 		m.err = msg.err
+		if m.cancelling {
+			m.err = fmt.Errorf("update cancelled")
+		}
 		return m, tea.Quit
 
 	case spinner.TickMsg:
-		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 	}
@@ -111,107 +100,15 @@ func (m UpdateModel) View() string {
 		return errorStyle.Render(fmt.Sprintf("Error: %v\n", m.err))
 	}
 
-	switch m.state {
-	case updateStateBuilding:
-		return fmt.Sprintf("%s Rebuilding %s...", m.spinner.View(), m.serverName)
-	case updateStateConfigEnv:
-		var b strings.Builder
-		b.WriteString(titleStyle.Render("Configuration Required"))
-		b.WriteString("\n\n")
-		for i := range m.inputs {
-			b.WriteString(m.inputs[i].View())
-			b.WriteString("\n")
-		}
-		b.WriteString("\n(Press Enter to confirm)")
-		return b.String()
-	case updateStateSelectingClient:
-		var b strings.Builder
-		b.WriteString(titleStyle.Render("Re-register with Clients?"))
-		b.WriteString("\n")
-		for i, choice := range m.clients {
-			cursor := " "
-			if m.cursor == i {
-				cursor = ">"
-			}
-			checked := "[ ]"
-			if m.selected[i] {
-				checked = "[x]"
-			}
-			line := fmt.Sprintf("%s %s %s", cursor, checked, choice)
-			if m.cursor == i {
-				b.WriteString(focusedStyle.Render(line))
-			} else {
-				b.WriteString(line)
-			}
-			b.WriteString("\n")
-		}
-		b.WriteString("\n(Space to toggle, Enter to update)")
-		return b.String()
-	case updateStateDone:
-		return successStyle.Render("Successfully updated and configured!")
-	}
-	return ""
-}
-
-func (m UpdateModel) updateEnvInputs(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "enter":
-		if m.focusIndex == len(m.inputs)-1 {
-			m.state = updateStateSelectingClient
-			return m, nil
-		}
-		m.inputs[m.focusIndex].Blur()
-		m.focusIndex++
-		m.inputs[m.focusIndex].Focus()
-		return m, nil
-
-	case "tab":
-		m.inputs[m.focusIndex].Blur()
-		m.focusIndex = (m.focusIndex + 1) % len(m.inputs)
-		m.inputs[m.focusIndex].Focus()
-		return m, nil
+	if m.cancelling {
+		return fmt.Sprintf("%s Cancelling, waiting for the running step to stop... (ctrl+c again to quit now)", m.spinner.View())
 	}
 
-	var cmd tea.Cmd
-	m.inputs[m.focusIndex], cmd = m.inputs[m.focusIndex].Update(msg)
-	return m, cmd
-}
-
-func (m UpdateModel) updateClientSelection(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "up", "k":
-		if m.cursor > 0 {
-			m.cursor--
-		}
-	case "down", "j":
-		if m.cursor < len(m.clients)-1 {
-			m.cursor++
-		}
-	case " ":
-		m.selected[m.cursor] = !m.selected[m.cursor]
-	case "enter":
-		m.state = updateStateDone
-
-		// Gather Env
-		finalEnv := make(map[string]string)
-		if m.buildResult != nil {
-			for i, need := range m.buildResult.EnvNeeds {
-				if i < len(m.inputs) {
-					finalEnv[need] = m.inputs[i].Value()
-				}
-			}
-		}
-
-		// Only register if at least one client is selected
-		if m.selected[0] || m.selected[1] {
-			// Import injector
-			err := registerClients(m.buildResult, m.selected, finalEnv, m.global)
-			if err != nil {
-				m.err = err
-				return m, tea.Quit
-			}
-		}
-		return m, tea.Quit
+	switch m.state {
+	case stateBuilding:
+		return fmt.Sprintf("%s Rebuilding %s...", m.spinner.View(), m.serverName)
+	case stateDone:
+		return renderDone("Successfully updated and configured!", m.buildResult)
 	}
-	return m, nil
+	return m.session.view()
 }